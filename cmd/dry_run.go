@@ -0,0 +1,32 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/aidanaden/canvas-sync/internal/pkg/canvas"
+	"github.com/aidanaden/canvas-sync/internal/pkg/utils"
+	"github.com/pterm/pterm"
+	"github.com/spf13/cobra"
+)
+
+// addDryRunFlags adds the flags of commands that can print a plan instead of syncing
+func addDryRunFlags(cmd *cobra.Command) {
+	cmd.Flags().Bool("dry-run", false, "print what would be downloaded, overwritten, renamed or pruned without writing anything")
+	cmd.Flags().StringP("output", "o", canvas.PLAN_FORMAT_TREE, "dry-run plan format: tree or json")
+}
+
+// setupDryRun validates the plan format, keeping stdout free for json plans
+func setupDryRun(cmd *cobra.Command) {
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
+	format, _ := cmd.Flags().GetString("output")
+	if !dryRun {
+		return
+	}
+	if err := canvas.ValidatePlanFormat(format); err != nil {
+		pterm.Error.Printfln(err.Error())
+		os.Exit(1)
+	}
+	if format == canvas.PLAN_FORMAT_JSON {
+		pterm.SetDefaultOutput(utils.Stderr)
+	}
+}
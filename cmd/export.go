@@ -0,0 +1,32 @@
+package cmd
+
+import (
+	"github.com/aidanaden/canvas-sync/internal/app/export"
+	"github.com/spf13/cobra"
+)
+
+// represents the export command
+var exportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export downloaded course data",
+}
+
+var exportSiteCmd = &cobra.Command{
+	Use:   "site",
+	Short: "Generate a browsable index.html of everything downloaded for each course",
+	Long: `Generate an index.html in each course directory linking to its downloaded syllabus, announcements, modules,
+pages, assignments, discussions, files and videos. All links are relative, so the course directory can be opened
+offline or zipped and shared.
+`,
+	Example: `  canvas-sync export site - exports all downloaded courses
+  canvas-sync export site CS3219 - exports course with course code "CS3219"`,
+	Run: func(cmd *cobra.Command, args []string) {
+		preRun(cmd)
+		export.RunExportSite(cmd, args)
+	},
+}
+
+func init() {
+	exportCmd.AddCommand(exportSiteCmd)
+	rootCmd.AddCommand(exportCmd)
+}
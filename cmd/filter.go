@@ -0,0 +1,22 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// addFileFilterFlags adds the flags of commands that sync course files
+func addFileFilterFlags(cmd *cobra.Command) {
+	cmd.Flags().StringSlice("include", nil, "only sync files whose canvas path matches one of these globs e.g. \"Lectures/**\"")
+	cmd.Flags().StringSlice("exclude", nil, "skip files and folders whose canvas path matches one of these globs e.g. \"Recordings/**\"")
+	cmd.Flags().StringSlice("type", nil, "only sync files of these extensions or content types e.g. pdf,pptx or video/*")
+	cmd.Flags().String("max-size", "", "skip files larger than this e.g. 50MB")
+}
+
+// bindFileFilterFlags binds the filter flags of the command being run, as several commands share the config keys
+func bindFileFilterFlags(cmd *cobra.Command) {
+	viper.BindPFlag("include", cmd.Flags().Lookup("include"))
+	viper.BindPFlag("exclude", cmd.Flags().Lookup("exclude"))
+	viper.BindPFlag("type", cmd.Flags().Lookup("type"))
+	viper.BindPFlag("max_size", cmd.Flags().Lookup("max-size"))
+}
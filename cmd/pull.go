@@ -23,16 +23,108 @@ var pullFilesCmd = &cobra.Command{
 	Short: "Downloads files for a given course (all if none specified)",
 	Example: `  canvas-sync pull files - downloads files for all courses
   canvas-sync pull files --data_dir /Users/test - downloads files for all courses in the /Users/test/files directory
-  canvas-sync pull files CS3219 CS3230 - downloads files for courses with course codes "CS3219" or "CS3230"`,
+  canvas-sync pull files CS3219 CS3230 - downloads files for courses with course codes "CS3219" or "CS3230"
+  canvas-sync pull files 45742 "software engineering" - courses can also be given by id, full name or nickname
+  canvas-sync pull files --include-completed --term "2023/2024 Semester 1" - downloads files for all courses from a past semester
+  canvas-sync pull files --exclude "Recordings/**" --type pdf,pptx --max-size 50MB - only downloads slides and documents up to 50MB
+  canvas-sync pull files --dry-run - prints the files that would be downloaded with their sizes, without downloading anything`,
 	Run: func(cmd *cobra.Command, args []string) {
+		setupDryRun(cmd)
+		bindFileFilterFlags(cmd)
 		preRun(cmd)
 		pull.RunPullFiles(cmd, args)
 	},
 }
 
+// represents the pull pages command
+var pullPagesCmd = &cobra.Command{
+	Use:   "pages",
+	Short: "Downloads pages as html and markdown for a given course (all if none specified)",
+	Example: `  canvas-sync pull pages - downloads pages for all courses into <course>/pages/
+  canvas-sync pull pages CS3219 CS3230 - downloads pages for courses with course codes "CS3219" or "CS3230"`,
+	Run: func(cmd *cobra.Command, args []string) {
+		preRun(cmd)
+		pull.RunPullPages(cmd, args)
+	},
+}
+
+// represents the pull assignments command
+var pullAssignmentsCmd = &cobra.Command{
+	Use:   "assignments",
+	Short: "Archives assignments with their attachments, rubrics, submissions and comments for a given course (all if none specified)",
+	Example: `  canvas-sync pull assignments - archives assignments for all courses into <course>/assignments/<name>/
+  canvas-sync pull assignments CS3219 - archives assignments for course with course code "CS3219"`,
+	Run: func(cmd *cobra.Command, args []string) {
+		preRun(cmd)
+		pull.RunPullAssignments(cmd, args)
+	},
+}
+
+// represents the pull discussions command
+var pullDiscussionsCmd = &cobra.Command{
+	Use:   "discussions",
+	Short: "Exports discussion topics with their replies and attachments for a given course (all if none specified)",
+	Example: `  canvas-sync pull discussions - exports discussions for all courses into <course>/discussions/<title>/
+  canvas-sync pull discussions CS3219 - exports discussions for course with course code "CS3219", only topics with new replies are rewritten on later runs`,
+	Run: func(cmd *cobra.Command, args []string) {
+		preRun(cmd)
+		pull.RunPullDiscussions(cmd, args)
+	},
+}
+
+// represents the pull syllabus command
+var pullSyllabusCmd = &cobra.Command{
+	Use:   "syllabus",
+	Short: "Downloads the syllabus and the files it links to for a given course (all if none specified)",
+	Example: `  canvas-sync pull syllabus - downloads the syllabus of all courses into <course>/syllabus/
+  canvas-sync pull syllabus CS3219 - downloads the syllabus of course with course code "CS3219"`,
+	Run: func(cmd *cobra.Command, args []string) {
+		preRun(cmd)
+		pull.RunPullSyllabus(cmd, args)
+	},
+}
+
+// represents the pull announcements command
+var pullAnnouncementsCmd = &cobra.Command{
+	Use:   "announcements",
+	Short: "Archives announcements with their attachments as markdown for a given course (all if none specified)",
+	Example: `  canvas-sync pull announcements - archives announcements for all courses into <course>/announcements/<date> <title>/
+  canvas-sync pull announcements CS3219 --mbox - also writes CS3219's announcements into <course>/announcements/announcements.mbox`,
+	Run: func(cmd *cobra.Command, args []string) {
+		preRun(cmd)
+		pull.RunPullAnnouncements(cmd, args)
+	},
+}
+
+// represents the pull modules command
+var pullModulesCmd = &cobra.Command{
+	Use:   "modules",
+	Short: "Downloads files published through modules for a given course (all if none specified)",
+	Example: `  canvas-sync pull modules - downloads module files for all courses into <course>/modules/<NN - Module name>/
+  canvas-sync pull modules CS3219 - downloads module files for course with course code "CS3219"
+  canvas-sync pull modules --type pdf --dry-run - prints the module pdfs that would be downloaded, without downloading anything`,
+	Run: func(cmd *cobra.Command, args []string) {
+		setupDryRun(cmd)
+		bindFileFilterFlags(cmd)
+		preRun(cmd)
+		pull.RunPullModules(cmd, args)
+	},
+}
+
 func init() {
 	pullCmd.AddCommand(pullFilesCmd)
+	pullCmd.AddCommand(pullModulesCmd)
+	pullCmd.AddCommand(pullPagesCmd)
+	pullCmd.AddCommand(pullAssignmentsCmd)
+	pullCmd.AddCommand(pullDiscussionsCmd)
+	pullCmd.AddCommand(pullSyllabusCmd)
+	pullCmd.AddCommand(pullAnnouncementsCmd)
+	pullAnnouncementsCmd.Flags().Bool("mbox", false, "also write the announcements into an mbox mailbox that can be imported into mail clients")
 	rootCmd.AddCommand(pullCmd)
+	for _, cmd := range []*cobra.Command{pullFilesCmd, pullModulesCmd} {
+		addDryRunFlags(cmd)
+		addFileFilterFlags(cmd)
+	}
 
 	rootCmd.PersistentFlags().StringP("data_dir", "d", "~/canvas-data", "downloaded data directory")
 	viper.BindPFlag("data_dir", rootCmd.PersistentFlags().Lookup("data_dir"))
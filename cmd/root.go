@@ -1,12 +1,17 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
+	"log"
 	"net/url"
 	"os"
+	"os/signal"
 	"strings"
+	"syscall"
 
 	"github.com/aidanaden/canvas-sync/internal/app/initialise"
+	"github.com/aidanaden/canvas-sync/internal/pkg/canvas"
 	"github.com/aidanaden/canvas-sync/internal/pkg/utils"
 	"github.com/pterm/pterm"
 	"github.com/spf13/cobra"
@@ -35,7 +40,17 @@ func SetVersionInfo(version, commit, date string) {
 // Execute adds all child commands to the root command and sets flags appropriately.
 // This is called by main.main(). It only needs to happen once to the rootCmd.
 func Execute() {
-	err := rootCmd.Execute()
+	// redact credentials from all terminal output and logs
+	pterm.SetDefaultOutput(utils.Stdout)
+	log.SetOutput(utils.Stderr)
+	rootCmd.SetOut(utils.Stdout)
+	rootCmd.SetErr(utils.Stderr)
+
+	// cancelled on ctrl-c so long-running commands can stop in-flight work and clean up
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	err := rootCmd.ExecuteContext(ctx)
 	if err != nil {
 		os.Exit(1)
 	}
@@ -50,6 +65,20 @@ func init() {
 	viper.BindPFlag("access_token", rootCmd.PersistentFlags().Lookup("access_token"))
 	rootCmd.PersistentFlags().StringP("canvas_url", "c", "https://canvas.nus.edu.sg", "canvas url e.g. canvas.nus.edu.sg")
 	viper.BindPFlag("canvas_url", rootCmd.PersistentFlags().Lookup("canvas_url"))
+	rootCmd.PersistentFlags().Int("max-concurrency", 8, "maximum number of requests to canvas awaiting a response at once across all courses")
+	viper.BindPFlag("max_concurrency", rootCmd.PersistentFlags().Lookup("max-concurrency"))
+	rootCmd.PersistentFlags().Int("workers", canvas.DEFAULT_WORKERS, "number of files downloaded at once across all courses")
+	viper.BindPFlag("workers", rootCmd.PersistentFlags().Lookup("workers"))
+	rootCmd.PersistentFlags().String("priority", string(canvas.PRIORITY_DEFAULT), "which files of each course are downloaded first: default (canvas order), small or newest")
+	viper.BindPFlag("priority", rootCmd.PersistentFlags().Lookup("priority"))
+	rootCmd.PersistentFlags().Int("max-retries", 4, "number of times a failed canvas request or file download is retried")
+	viper.BindPFlag("max_retries", rootCmd.PersistentFlags().Lookup("max-retries"))
+	rootCmd.PersistentFlags().Bool("include-completed", false, "include courses from concluded enrollments e.g. past semesters")
+	viper.BindPFlag("include_completed", rootCmd.PersistentFlags().Lookup("include-completed"))
+	rootCmd.PersistentFlags().Bool("all-enrollments", false, "include courses from all enrollments (active, pending and concluded)")
+	viper.BindPFlag("all_enrollments", rootCmd.PersistentFlags().Lookup("all-enrollments"))
+	rootCmd.PersistentFlags().String("term", "", "only include courses from terms matching this name e.g. \"2023/2024 Semester 2\"")
+	viper.BindPFlag("term", rootCmd.PersistentFlags().Lookup("term"))
 
 	viper.SetDefault("author", "ryan aidan aidan@u.nus.edu")
 	viper.SetDefault("license", "MIT")
@@ -111,6 +140,9 @@ func preRun(cmd *cobra.Command) {
 		os.Exit(1)
 	}
 
+	utils.RegisterSecret(viper.GetString("access_token"))
+	utils.RegisterSecret(viper.GetString("canvas_password"))
+
 	canvasUrl := fmt.Sprintf("%v", viper.Get("canvas_url"))
 	_, err := url.Parse(canvasUrl)
 	if err != nil {
@@ -0,0 +1,67 @@
+package cmd
+
+import (
+	"errors"
+
+	"github.com/aidanaden/canvas-sync/internal/app/trash"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// represents the trash command
+var trashCmd = &cobra.Command{
+	Use:   "trash",
+	Short: "Manage downloaded files that were deleted from canvas",
+	Long: `Manage files moved into each course's .canvas-sync-trash folder by 'canvas-sync update files --prune'
+`,
+}
+
+var trashListCmd = &cobra.Command{
+	Use:     "list",
+	Aliases: []string{"ls"},
+	Short:   "List trashed files",
+	Example: `  canvas-sync trash list - lists trashed files for all courses
+  canvas-sync trash list CS3219 - lists trashed files for course with course code "CS3219"`,
+	Run: func(cmd *cobra.Command, args []string) {
+		preRun(cmd)
+		trash.RunTrashList(cmd, args)
+	},
+}
+
+var trashRestoreCmd = &cobra.Command{
+	Use:   "restore",
+	Short: "Restore trashed files to their original location",
+	Example: `  canvas-sync trash restore CS3219 "files/Week 3/answers.pdf" - restores the most recently trashed copy of a file
+  canvas-sync trash restore CS3219 "2024-03-01/files/Week 3/answers.pdf" - restores the copy trashed on a given date`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if len(args) < 2 {
+			return errors.New("a course code and at least one trashed file are required")
+		}
+		return nil
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		preRun(cmd)
+		trash.RunTrashRestore(cmd, args)
+	},
+}
+
+var trashEmptyCmd = &cobra.Command{
+	Use:   "empty",
+	Short: "Permanently delete trashed files",
+	Example: `  canvas-sync trash empty - deletes trashed files for all courses
+  canvas-sync trash empty CS3219 --yes - deletes trashed files for course with course code "CS3219" without confirming`,
+	Run: func(cmd *cobra.Command, args []string) {
+		preRun(cmd)
+		trash.RunTrashEmpty(cmd, args)
+	},
+}
+
+func init() {
+	trashCmd.AddCommand(trashListCmd)
+	trashCmd.AddCommand(trashRestoreCmd)
+	trashCmd.AddCommand(trashEmptyCmd)
+	rootCmd.AddCommand(trashCmd)
+
+	trashEmptyCmd.Flags().BoolP("yes", "y", false, "skip the confirmation prompt")
+	viper.BindPFlag("yes", trashEmptyCmd.Flags().Lookup("yes"))
+}
@@ -19,17 +19,67 @@ var updateFilesCmd = &cobra.Command{
 	Short: "Updates locally downloaded course files from canvas",
 	Example: `  canvas-sync update files - updates all downloaded files for all courses
   canvas-sync update files CS3219 - updates all files for course with course code "CS3219"
-  canvas-sync update files CS3219 CS3230 - updates all files for courses with course codes "CS3219" or "CS3230"`,
+  canvas-sync update files CS3219 CS3230 - updates all files for courses with course codes "CS3219" or "CS3230"
+  canvas-sync update files --prune - moves downloaded files that were deleted from canvas into each course's trash
+  canvas-sync update files --verify - rehashes downloaded files and redownloads any that are corrupted or were modified locally
+  canvas-sync update files --dry-run --prune -o json - prints what would be downloaded, renamed or pruned as json, without writing anything`,
 	Run: func(cmd *cobra.Command, args []string) {
+		setupDryRun(cmd)
+		bindFileFilterFlags(cmd)
+		bindUpdateFlags(cmd)
 		preRun(cmd)
 		update.RunUpdateFiles(cmd, args)
 	},
 }
 
+var updateModulesCmd = &cobra.Command{
+	Use:   "modules",
+	Short: "Updates locally downloaded module files from canvas",
+	Example: `  canvas-sync update modules - updates all downloaded module files and module READMEs for all courses
+  canvas-sync update modules CS3219 - updates module files for course with course code "CS3219"
+  canvas-sync update modules --prune - moves downloaded module files that were removed from their modules into each course's trash
+  canvas-sync update modules --dry-run - prints what would be downloaded, renamed or pruned, without writing anything`,
+	Run: func(cmd *cobra.Command, args []string) {
+		setupDryRun(cmd)
+		bindFileFilterFlags(cmd)
+		bindUpdateFlags(cmd)
+		preRun(cmd)
+		update.RunUpdateModules(cmd, args)
+	},
+}
+
+var updatePagesCmd = &cobra.Command{
+	Use:   "pages",
+	Short: "Updates locally downloaded pages that changed on canvas",
+	Example: `  canvas-sync update pages - updates pages edited since they were downloaded for all courses
+  canvas-sync update pages CS3219 - updates pages for course with course code "CS3219"`,
+	Run: func(cmd *cobra.Command, args []string) {
+		preRun(cmd)
+		update.RunUpdatePages(cmd, args)
+	},
+}
+
+func addUpdateFlags(cmd *cobra.Command) {
+	cmd.Flags().BoolP("force", "f", false, "overwrite downloaded files if there's a newer version on canvas")
+	cmd.Flags().Bool("verify", false, "rehash downloaded files against the sync manifest and redownload any that don't match")
+	cmd.Flags().Bool("prune", false, "move downloaded files that were deleted from canvas into the course's .canvas-sync-trash folder")
+}
+
+// bindUpdateFlags binds the running command's update flags, several commands share the same keys
+func bindUpdateFlags(cmd *cobra.Command) {
+	viper.BindPFlag("force", cmd.Flags().Lookup("force"))
+	viper.BindPFlag("verify", cmd.Flags().Lookup("verify"))
+	viper.BindPFlag("prune", cmd.Flags().Lookup("prune"))
+}
+
 func init() {
 	updateCmd.AddCommand(updateFilesCmd)
+	updateCmd.AddCommand(updateModulesCmd)
+	updateCmd.AddCommand(updatePagesCmd)
 	rootCmd.AddCommand(updateCmd)
-
-	updateFilesCmd.PersistentFlags().BoolP("force", "f", false, "overwrite downloaded files if there's a newer version on canvas")
-	viper.BindPFlag("force", updateFilesCmd.PersistentFlags().Lookup("force"))
+	for _, cmd := range []*cobra.Command{updateFilesCmd, updateModulesCmd} {
+		addDryRunFlags(cmd)
+		addFileFilterFlags(cmd)
+		addUpdateFlags(cmd)
+	}
 }
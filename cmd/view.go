@@ -16,7 +16,7 @@ var VIEW_PEOPLE_ALIASES = []string{"classmates", "class", "students"}
 var viewCmd = &cobra.Command{
 	Use:     "view",
 	Aliases: VIEW_ALIASES,
-	Short:   "View data from canvas (events, deadlines, people)",
+	Short:   "View data from canvas (events, deadlines, people, syllabus)",
 }
 
 // represents the view people command
@@ -37,6 +37,23 @@ var viewPeopleCmd = &cobra.Command{
 	Example: "  canvas-sync view people cs3230",
 }
 
+// represents the view syllabus command
+var viewSyllabusCmd = &cobra.Command{
+	Use:   "syllabus",
+	Short: "View the syllabus of a given course (case-insensitive)",
+	Run: func(cmd *cobra.Command, args []string) {
+		preRun(cmd)
+		view.RunViewCourseSyllabus(cmd, args)
+	},
+	Args: func(cmd *cobra.Command, args []string) error {
+		if len(args) != 1 {
+			return errors.New("no valid course code provided")
+		}
+		return nil
+	},
+	Example: "  canvas-sync view syllabus cs3230",
+}
+
 // represents the view events command
 var viewEventsCmd = &cobra.Command{
 	Use:   "events",
@@ -124,6 +141,7 @@ func init() {
 
 	viewCmd.AddCommand(viewPeopleCmd)
 	viewCmd.AddCommand(viewAnnouncementsCmd)
+	viewCmd.AddCommand(viewSyllabusCmd)
 
 	rootCmd.AddCommand(viewCmd)
 
@@ -140,5 +158,5 @@ func init() {
 
 // view events: https://canvas.nus.edu.sg/api/v1/calendar_events?per_page=100&type=assignment&context_codes%5B%5D=course_45742&all_events=1&excludes%5B%5D=assignment&excludes%5B%5D=description&excludes%5B%5D=child_events
 // view people: https://canvas.nus.edu.sg/api/v1/courses/45742/users?include%5B%5D=avatar_url&include%5B%5D=enrollments&include%5B%5D=email&include%5B%5D=observed_users&include%5B%5D=can_be_removed&include%5B%5D=custom_links&include_inactive=true&page=2&per_page=50
-// view syllabus: server-rendered table, will need to scrape
+// view syllabus: https://canvas.nus.edu.sg/api/v1/courses/45742?include%5B%5D=syllabus_body
 // view grades: client-rendered table, will need to use playwright
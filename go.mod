@@ -3,13 +3,17 @@ module github.com/aidanaden/canvas-sync
 go 1.21.1
 
 require (
+	github.com/bmatcuk/doublestar/v4 v4.10.2
 	github.com/chelnak/ysmrr v0.3.0
 	github.com/grokify/html-strip-tags-go v0.0.1
+	github.com/lithammer/fuzzysearch v1.1.8
+	github.com/mattn/go-colorable v0.1.13
 	github.com/playwright-community/playwright-go v0.3700.0
 	github.com/pterm/pterm v0.12.69
 	github.com/spf13/cobra v1.7.0
 	github.com/spf13/viper v1.16.0
 	github.com/u2takey/ffmpeg-go v0.5.0
+	golang.org/x/net v0.21.0
 	gopkg.in/vansante/go-ffprobe.v2 v2.1.1
 )
 
@@ -28,9 +32,7 @@ require (
 	github.com/hashicorp/hcl v1.0.0 // indirect
 	github.com/inconshreveable/mousetrap v1.1.0 // indirect
 	github.com/jmespath/go-jmespath v0.4.0 // indirect
-	github.com/lithammer/fuzzysearch v1.1.8 // indirect
 	github.com/magiconair/properties v1.8.7 // indirect
-	github.com/mattn/go-colorable v0.1.13 // indirect
 	github.com/mattn/go-isatty v0.0.19 // indirect
 	github.com/mattn/go-runewidth v0.0.15 // indirect
 	github.com/mitchellh/mapstructure v1.5.0 // indirect
@@ -44,9 +46,9 @@ require (
 	github.com/u2takey/go-utils v0.3.1 // indirect
 	github.com/xo/terminfo v0.0.0-20220910002029-abceb7e1c41e // indirect
 	go.uber.org/multierr v1.11.0 // indirect
-	golang.org/x/sys v0.12.0 // indirect
-	golang.org/x/term v0.11.0 // indirect
-	golang.org/x/text v0.12.0 // indirect
+	golang.org/x/sys v0.17.0 // indirect
+	golang.org/x/term v0.17.0 // indirect
+	golang.org/x/text v0.14.0 // indirect
 	gopkg.in/ini.v1 v1.67.0 // indirect
 	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
@@ -0,0 +1,182 @@
+package courses
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/aidanaden/canvas-sync/internal/pkg/canvas"
+	"github.com/aidanaden/canvas-sync/internal/pkg/config"
+	"github.com/aidanaden/canvas-sync/internal/pkg/manifest"
+	"github.com/aidanaden/canvas-sync/internal/pkg/nodes"
+	"github.com/aidanaden/canvas-sync/internal/pkg/utils"
+	"github.com/chelnak/ysmrr"
+	"github.com/chelnak/ysmrr/pkg/colors"
+	"github.com/pterm/pterm"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// Options describes the sync a Run performs for every course
+type Options struct {
+	// content being synced and how, shown in spinners and errors e.g. "files" and "download"
+	Dir  string
+	Verb string
+	// past tense of the sync for the final success line e.g. "Downloaded"
+	Done string
+	// leave every manifest unsaved and print the plans set by the action (in PlanFormat) instead of the report
+	DryRun     bool
+	PlanFormat string
+}
+
+// Course is the course an Action syncs, with its loaded manifest
+type Course struct {
+	Node     nodes.CourseNode
+	Dir      string
+	Manifest *manifest.Manifest
+	Report   *canvas.SyncReport
+	spinner  *ysmrr.Spinner
+	index    int
+	plans    []*canvas.CoursePlan
+}
+
+// Status updates the course's spinner with the current step
+func (c Course) Status(format string, args ...any) {
+	c.spinner.UpdateMessagef(pterm.FgCyan.Sprintf(format, args...))
+}
+
+// SetPlan records what a dry run would do for the course
+func (c Course) SetPlan(plan *canvas.CoursePlan) {
+	c.plans[c.index] = plan
+}
+
+// Action syncs a single course, returning the message shown once it's done
+type Action func(ctx context.Context, client *canvas.CanvasClient, course Course) (string, error)
+
+// Run resolves the given courses (all if none) and runs action for each of them concurrently, each with its own
+// spinner. Manifests are saved after the action even if it failed or was cancelled, so finished downloads are kept.
+// Exits on interruption or if any course failed, after printing the sync report (or the plans in a dry run).
+func Run(cmd *cobra.Command, args []string, opts Options, action Action) {
+	ctx := cmd.Context()
+	targetDir := fmt.Sprintf("%s", viper.Get("data_dir"))
+	targetDir = utils.GetExpandedHomeDirectoryPath(targetDir)
+	accessToken := fmt.Sprintf("%v", viper.Get("access_token"))
+	canvasUrl := fmt.Sprintf("%v", viper.Get("canvas_url"))
+
+	providedCodes := utils.GetCourseCodesFromArgs(args)
+	pterm.Info.Printfln("Downloading %s to: %s", opts.Dir, targetDir)
+
+	if accessToken == "" {
+		pterm.Error.Printfln("Invalid config, please run 'canvas-sync init'")
+		os.Exit(1)
+	}
+	canvasClient := canvas.NewClient(canvasUrl, accessToken, config.ClientOptions()...)
+	defer canvasClient.Close()
+
+	courses, err := canvasClient.ResolveCourses(ctx, providedCodes)
+	if err != nil {
+		canvas.ExitOnError("Failed to find courses", err)
+	}
+
+	pterm.Println()
+	var wg sync.WaitGroup
+	var errMu sync.Mutex
+	var courseErr error
+	report := &canvas.SyncReport{}
+	plans := make([]*canvas.CoursePlan, len(courses))
+	setCourseErr := func(err error) {
+		errMu.Lock()
+		defer errMu.Unlock()
+		if courseErr == nil {
+			courseErr = err
+		}
+	}
+	output := utils.Stdout
+	if opts.DryRun && opts.PlanFormat == canvas.PLAN_FORMAT_JSON {
+		// keep stdout for the json plan
+		output = utils.Stderr
+	}
+	sm := ysmrr.NewSpinnerManager(
+		ysmrr.WithCompleteColor(colors.FgHiGreen),
+		ysmrr.WithSpinnerColor(colors.FgHiBlue),
+		ysmrr.WithWriter(output),
+	)
+
+	for ci := range courses {
+		wg.Add(1)
+		sp := sm.AddSpinner(fmt.Sprintf("Starting %s %s for %s...", opts.Dir, opts.Verb, courses[ci].CourseCode))
+		go func(i int, sp *ysmrr.Spinner) {
+			defer wg.Done()
+			code := courses[i].CourseCode
+
+			courseDir := filepath.Join(targetDir, code)
+			m, err := manifest.Load(courseDir, courses[i].ID)
+			if err != nil {
+				sp.UpdateMessagef(pterm.Error.Sprintf("Failed to load sync manifest for %s: %s", code, err.Error()))
+				sp.Error()
+				setCourseErr(err)
+				return
+			}
+
+			message, actionErr := action(ctx, canvasClient, Course{
+				Node:     courses[i],
+				Dir:      courseDir,
+				Manifest: m,
+				Report:   report,
+				spinner:  sp,
+				index:    i,
+				plans:    plans,
+			})
+			// save whatever finished, even if cancelled
+			if !opts.DryRun {
+				if err := m.Save(); err != nil {
+					sp.UpdateMessagef(pterm.Error.Sprintf("Failed to save sync manifest for %s: %s", code, err.Error()))
+					sp.Error()
+					setCourseErr(err)
+					return
+				}
+			}
+
+			if ctx.Err() != nil {
+				sp.UpdateMessagef(pterm.FgYellow.Sprintf("Cancelled %s %s for %s", opts.Dir, opts.Verb, code))
+				sp.Error()
+				return
+			}
+			if actionErr != nil {
+				sp.UpdateMessagef(pterm.Error.Sprintf("Failed to %s %s for %s: %s", opts.Verb, opts.Dir, code, canvas.ErrorWithHint(actionErr)))
+				sp.Error()
+				setCourseErr(actionErr)
+				return
+			}
+
+			sp.UpdateMessagef(pterm.FgGreen.Sprint(message))
+			sp.Complete()
+		}(ci, sp)
+	}
+
+	sm.Start()
+	wg.Wait()
+	sm.Stop()
+	pterm.Println()
+	if opts.DryRun {
+		if err := canvas.PrintPlans(utils.Stdout, plans, opts.PlanFormat); err != nil {
+			canvas.ExitOnError("Failed to print plan", err)
+		}
+		if courseErr != nil {
+			canvas.ExitOnError(fmt.Sprintf("Failed to plan %s %s for some courses", opts.Dir, opts.Verb), courseErr)
+		}
+		return
+	}
+	if ctx.Err() != nil {
+		report.PrintInterrupted()
+		report.Print()
+		os.Exit(canvas.EXIT_INTERRUPTED)
+	}
+	report.Print()
+	if courseErr != nil {
+		canvas.ExitOnError(fmt.Sprintf("Failed to %s %s for some courses", opts.Verb, opts.Dir), courseErr)
+	}
+	pterm.Success.Printfln("%s %s: %s", opts.Done, opts.Dir, targetDir)
+}
@@ -0,0 +1,26 @@
+package documents
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aidanaden/canvas-sync/internal/app/courses"
+	"github.com/aidanaden/canvas-sync/internal/pkg/canvas"
+	"github.com/spf13/cobra"
+)
+
+// Run syncs source's documents for every given course (all if none) into <data_dir>/<course>/<source.Dir>
+func Run(cmd *cobra.Command, args []string, source canvas.DocumentSource, opts canvas.DocumentOptions) {
+	courses.Run(cmd, args, courses.Options{Dir: source.Dir, Verb: "download", Done: "Downloaded"}, func(ctx context.Context, canvasClient *canvas.CanvasClient, course courses.Course) (string, error) {
+		code := course.Node.CourseCode
+		course.Status("Pulling %s for %s", source.Dir, code)
+		written, err := source.Sync(ctx, canvasClient, course.Node, course.Dir, course.Manifest, opts, course.Report)
+		if err != nil {
+			return "", err
+		}
+		if written > 0 {
+			return fmt.Sprintf("Downloaded %d %s for %s", written, source.Dir, code), nil
+		}
+		return fmt.Sprintf("All %s are up-to-date for %s", source.Dir, code), nil
+	})
+}
@@ -0,0 +1,48 @@
+package export
+
+import (
+	"os"
+
+	"github.com/aidanaden/canvas-sync/internal/pkg/canvas"
+	"github.com/aidanaden/canvas-sync/internal/pkg/site"
+	"github.com/aidanaden/canvas-sync/internal/pkg/utils"
+	"github.com/pterm/pterm"
+	"github.com/spf13/cobra"
+)
+
+// where the pull commands save course content
+var courseLayout = site.Layout{
+	SyllabusDir:            canvas.SYLLABUS_DIR,
+	SyllabusDocument:       canvas.SYLLABUS_DOCUMENT,
+	SyllabusAttachmentsDir: canvas.SYLLABUS_ATTACHMENTS_DIR,
+	AnnouncementsDir:       canvas.ANNOUNCEMENTS_DIR,
+	AnnouncementDocument:   canvas.ANNOUNCEMENT_DOCUMENT,
+	ModulesDir:             canvas.MODULES_DIR,
+	PagesDir:               canvas.PAGES_DIR,
+	AssignmentsDir:         canvas.ASSIGNMENTS_DIR,
+	AssignmentDocument:     canvas.ASSIGNMENT_DOCUMENT,
+	DiscussionsDir:         canvas.DISCUSSIONS_DIR,
+	DiscussionDocument:     canvas.DISCUSSION_DOCUMENT,
+	FilesDir:               canvas.FILES_DIR,
+	VideosDir:              canvas.VIDEOS_DIR,
+	PartSuffix:             canvas.PART_SUFFIX,
+}
+
+func RunExportSite(cmd *cobra.Command, args []string) {
+	failed := false
+	for _, course := range utils.GetCourseDirs(utils.GetCourseCodesFromArgs(args)) {
+		indexPath, sections, err := site.Export(course.Dir, course.Code, courseLayout)
+		if err != nil {
+			pterm.Error.Printfln("Failed to export %s: %s", course.Code, err.Error())
+			failed = true
+			continue
+		}
+		if len(sections) == 0 {
+			pterm.Warning.Printfln("Nothing downloaded for %s yet, run 'canvas-sync pull' first", course.Code)
+		}
+		pterm.Success.Printfln("Exported %s to %s", course.Code, indexPath)
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
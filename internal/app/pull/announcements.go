@@ -0,0 +1,12 @@
+package pull
+
+import (
+	"github.com/aidanaden/canvas-sync/internal/app/documents"
+	"github.com/aidanaden/canvas-sync/internal/pkg/canvas"
+	"github.com/spf13/cobra"
+)
+
+func RunPullAnnouncements(cmd *cobra.Command, args []string) {
+	mbox, _ := cmd.Flags().GetBool("mbox")
+	documents.Run(cmd, args, canvas.AnnouncementsSource, canvas.DocumentOptions{Mbox: mbox})
+}
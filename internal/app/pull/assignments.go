@@ -0,0 +1,11 @@
+package pull
+
+import (
+	"github.com/aidanaden/canvas-sync/internal/app/documents"
+	"github.com/aidanaden/canvas-sync/internal/pkg/canvas"
+	"github.com/spf13/cobra"
+)
+
+func RunPullAssignments(cmd *cobra.Command, args []string) {
+	documents.Run(cmd, args, canvas.AssignmentsSource, canvas.DocumentOptions{})
+}
@@ -1,106 +1,61 @@
 package pull
 
 import (
+	"context"
 	"fmt"
-	"os"
 	"path/filepath"
-	"strings"
-	"sync"
 
+	"github.com/aidanaden/canvas-sync/internal/app/courses"
 	"github.com/aidanaden/canvas-sync/internal/pkg/canvas"
-	"github.com/aidanaden/canvas-sync/internal/pkg/nodes"
-	"github.com/aidanaden/canvas-sync/internal/pkg/utils"
-	"github.com/chelnak/ysmrr"
-	"github.com/chelnak/ysmrr/pkg/colors"
-	"github.com/pterm/pterm"
+	"github.com/aidanaden/canvas-sync/internal/pkg/config"
 	"github.com/spf13/cobra"
-	"github.com/spf13/viper"
 )
 
 func RunPullFiles(cmd *cobra.Command, args []string) {
-	targetDir := fmt.Sprintf("%s", viper.Get("data_dir"))
-	targetDir = utils.GetExpandedHomeDirectoryPath(targetDir)
-	accessToken := fmt.Sprintf("%v", viper.Get("access_token"))
-	canvasUrl := fmt.Sprintf("%v", viper.Get("canvas_url"))
+	runPullTree(cmd, args, canvas.FilesSource)
+}
 
-	providedCodes := utils.GetCourseCodesFromArgs(args)
-	pterm.Info.Printfln("Downloading files to: %s", targetDir)
+// runPullTree syncs every course's tree from source into <data_dir>/<course>/<source.Dir>
+func runPullTree(cmd *cobra.Command, args []string, source canvas.TreeSource) {
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
+	planFormat, _ := cmd.Flags().GetString("output")
+	opts := courses.Options{Dir: source.Dir, Verb: "download", Done: "Downloaded", DryRun: dryRun, PlanFormat: planFormat}
+	courses.Run(cmd, args, opts, func(ctx context.Context, canvasClient *canvas.CanvasClient, course courses.Course) (string, error) {
+		code := course.Node.CourseCode
+		filter, err := config.FileFilter(course.Node)
+		if err != nil {
+			return "", fmt.Errorf("invalid file filter: %w", err)
+		}
 
-	if accessToken == "" {
-		pterm.Error.Printfln("Invalid config, please run 'canvas-sync init'")
-		os.Exit(1)
-	}
-	canvasClient := canvas.NewClient(canvasUrl, accessToken)
+		course.Status("Pulling %s info for %s", source.Dir, code)
+		rootNode, afterSync, err := source.Fetch(ctx, canvasClient, course.Node.ID, filepath.Join(course.Dir, source.Dir), filter, course.Report)
+		if err != nil {
+			return "", fmt.Errorf("failed to fetch %s info: %w", source.Dir, err)
+		}
 
-	rawCourses, err := canvasClient.GetActiveEnrolledCourses()
-	if err != nil {
-		pterm.Error.Printfln("Failed to fetch actively enrolled courses: %s", err.Error())
-		os.Exit(1)
-	}
-	courses := make([]nodes.CourseNode, 0)
-	for _, raw := range rawCourses {
-		if raw.CourseCode == "" {
-			continue
+		if dryRun {
+			plan := canvas.PlanCreate(code, rootNode)
+			course.SetPlan(plan)
+			return fmt.Sprintf("Planned %d files for %s", len(plan.Files), code), nil
 		}
-		// add any course if no code provided
-		if len(providedCodes) == 0 {
-			courses = append(courses, raw)
-			continue
+
+		course.Status("Downloading %s for %s", source.Dir, code)
+		totalFileDownloads := 0
+		if err := canvasClient.RecursiveCreateNode(ctx, rootNode, course.Manifest, course.Report, func(numDownloads int) {
+			totalFileDownloads += numDownloads
+			course.Status("Downloading %d files for %s", totalFileDownloads, code)
+		}); err != nil {
+			return "", fmt.Errorf("failed to recurse download files: %w", err)
 		}
-		// add course if code matches provided codes
-		for _, provided := range providedCodes {
-			if strings.ToLower(raw.CourseCode) == provided {
-				courses = append(courses, raw)
+		if afterSync != nil && ctx.Err() == nil {
+			if err := afterSync(); err != nil {
+				return "", err
 			}
 		}
-	}
-
-	pterm.Println()
-	var wg sync.WaitGroup
-	sm := ysmrr.NewSpinnerManager(
-		ysmrr.WithCompleteColor(colors.FgHiGreen),
-		ysmrr.WithSpinnerColor(colors.FgHiBlue),
-	)
-
-	for ci := range courses {
-		wg.Add(1)
-		sp := sm.AddSpinner(fmt.Sprintf("Starting files download for %s...", courses[ci].CourseCode))
-		go func(i int, sp *ysmrr.Spinner) {
-			defer wg.Done()
-			id := courses[i].ID
-			code := courses[i].CourseCode
-
-			rootNode, err := canvasClient.GetCourseRootFolder(id)
-			if err != nil {
-				sp.UpdateMessagef(pterm.Error.Sprintf("Failed to fetch course root folder: %s", err.Error()))
-				sp.Error()
-			}
-			rootNode.Name = filepath.Join(targetDir, code, "files")
-
-			sp.UpdateMessagef(pterm.FgCyan.Sprintf("Pulling files info for %s", code))
-			if err := canvasClient.RecurseDirectoryNode(rootNode, nil); err != nil {
-				sp.UpdateMessagef(pterm.Error.Sprintf("Failed to recurse directories: %s", err.Error()))
-				sp.Error()
-			}
-
-			sp.UpdateMessagef(pterm.FgCyan.Sprintf("Downloading files for %s", code))
-			totalFileDownloads := 0
-			if err := canvasClient.RecursiveCreateNode(rootNode, func(numDownloads int) {
-				totalFileDownloads += numDownloads
-				sp.UpdateMessagef(pterm.FgCyan.Sprintf("Downloading %d files for %s", totalFileDownloads, code))
-			}); err != nil {
-				sp.UpdateMessagef(pterm.Error.Sprintf("Failed to recurse download files: %s", err.Error()))
-				sp.Error()
-			}
-
-			sp.UpdateMessagef(pterm.FgGreen.Sprintf("Downloaded %d files for %s", totalFileDownloads, code))
-			sp.Complete()
-		}(ci, sp)
-	}
+		return fmt.Sprintf("Downloaded %d files for %s", totalFileDownloads, code), nil
+	})
+}
 
-	sm.Start()
-	wg.Wait()
-	sm.Stop()
-	pterm.Println()
-	pterm.Success.Printfln("Downloaded files: %s", targetDir)
+func RunPullModules(cmd *cobra.Command, args []string) {
+	runPullTree(cmd, args, canvas.ModulesSource)
 }
@@ -1,13 +1,14 @@
 package pull
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"net/url"
 	"os"
 	"path/filepath"
-	"strings"
 	"sync"
+	"sync/atomic"
 
 	"github.com/aidanaden/canvas-sync/internal/pkg/canvas"
 	"github.com/aidanaden/canvas-sync/internal/pkg/config"
@@ -22,17 +23,28 @@ import (
 	ffmpeg_go "github.com/u2takey/ffmpeg-go"
 )
 
-func getBrowser() (playwright.Browser, error) {
+// getBrowser launches chrome, returning a func that closes both the browser and playwright driver.
+// Both are also closed once ctx is cancelled, aborting any pending page actions.
+func getBrowser(ctx context.Context) (playwright.Browser, func(), error) {
 	pw, err := playwright.Run()
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	CHANNEL := "chrome"
 	bw, err := pw.Chromium.Launch(playwright.BrowserTypeLaunchOptions{Channel: &CHANNEL})
 	if err != nil {
-		return nil, err
+		pw.Stop()
+		return nil, nil, err
 	}
-	return bw, nil
+	var once sync.Once
+	closeBrowser := func() {
+		once.Do(func() {
+			bw.Close()
+			pw.Stop()
+		})
+	}
+	context.AfterFunc(ctx, closeBrowser)
+	return bw, closeBrowser, nil
 }
 
 func getPage(bw playwright.Browser) (playwright.Page, error) {
@@ -54,7 +66,41 @@ func extractVideosFromDirectory(folder *canvas.CourseVideoFolder) []*canvas.Cour
 
 const MAX_DOWNLOAD_ATTEMPTS = 5
 
+// downloadVideo downloads a video's stream(s) with ffmpeg, merging separate audio/video streams.
+// ffmpeg is killed if ctx is cancelled and partial output is always removed on failure.
+func downloadVideo(ctx context.Context, fil *canvas.CourseVideoFile) error {
+	var stream *ffmpeg_go.Stream
+	if fil.VideoUrl == "" {
+		// if only 1 source file, simply download
+		stream = ffmpeg_go.Input(fil.AudioUrl).Output(fil.Path, ffmpeg_go.KwArgs{"c": "copy"})
+	} else {
+		// if 2 source files, merge audio of audio file into video files
+		main := ffmpeg_go.Input(fil.VideoUrl)
+		overlay := ffmpeg_go.Input(fil.AudioUrl)
+		stream = ffmpeg_go.Output(
+			[]*ffmpeg_go.Stream{main, overlay},
+			fil.Path,
+			ffmpeg_go.KwArgs{"map": "1:a,0:v", "c:v": "copy"},
+		)
+	}
+	// ffmpeg is run via exec.CommandContext with the stream's context
+	stream.Context = ctx
+	stream = stream.Silent(true).OverWriteOutput().WithOutput(io.Discard)
+
+	var err error
+	for i := 0; i < MAX_DOWNLOAD_ATTEMPTS; i++ {
+		if err = stream.Run(); err == nil || ctx.Err() != nil {
+			break
+		}
+	}
+	if err != nil {
+		os.Remove(fil.Path)
+	}
+	return err
+}
+
 func RunPullVideos(cmd *cobra.Command, args []string, isUpdate bool) {
+	ctx := cmd.Context()
 	targetDir := fmt.Sprintf("%s", viper.Get("data_dir"))
 	targetDir = utils.GetExpandedHomeDirectoryPath(targetDir)
 	username := fmt.Sprintf("%v", viper.Get("canvas_username"))
@@ -74,36 +120,18 @@ func RunPullVideos(cmd *cobra.Command, args []string, isUpdate bool) {
 		os.Exit(1)
 	}
 
-	canvasClient := canvas.NewClient(canvasUrl, accessToken)
-	rawCourses, err := canvasClient.GetActiveEnrolledCourses()
+	canvasClient := canvas.NewClient(canvasUrl, accessToken, config.ClientOptions()...)
+	courses, err := canvasClient.ResolveCourses(ctx, providedCodes)
 	if err != nil {
-		pterm.Error.Printfln("Error: failed to fetch all actively enrolled courses: %s", err.Error())
-		os.Exit(1)
-	}
-	courses := make([]nodes.CourseNode, 0)
-	for _, raw := range rawCourses {
-		if raw.CourseCode == "" {
-			continue
-		}
-		// add any course if no code provided
-		if len(providedCodes) == 0 {
-			courses = append(courses, raw)
-			continue
-		}
-		// add course if code matches provided codes
-		for _, provided := range providedCodes {
-			if strings.ToLower(raw.CourseCode) == provided {
-				courses = append(courses, raw)
-			}
-		}
+		canvas.ExitOnError("Failed to find courses", err)
 	}
 
-	bw, err := getBrowser()
+	bw, closeBrowser, err := getBrowser(ctx)
 	if err != nil {
 		pterm.Error.Printfln("Error getting browser: %s", err.Error())
 		os.Exit(1)
 	}
-	defer bw.Close()
+	defer closeBrowser()
 
 	page, err := getPage(bw)
 	if err != nil {
@@ -146,6 +174,7 @@ func RunPullVideos(cmd *cobra.Command, args []string, isUpdate bool) {
 	sm := ysmrr.NewSpinnerManager(
 		ysmrr.WithCompleteColor(colors.FgHiGreen),
 		ysmrr.WithSpinnerColor(colors.FgHiBlue),
+		ysmrr.WithWriter(utils.Stdout),
 	)
 
 	type SpinnerCount struct {
@@ -179,6 +208,7 @@ func RunPullVideos(cmd *cobra.Command, args []string, isUpdate bool) {
 
 	sm.Start()
 	var wg sync.WaitGroup
+	var downloadedVideos atomic.Int64
 
 	pterm.Println()
 
@@ -198,9 +228,14 @@ func RunPullVideos(cmd *cobra.Command, args []string, isUpdate bool) {
 				return
 			}
 
-			rootFolder, err := canvasClient.GetCourseVideos(page, targetDir, c, func(isFile bool) {
+			rootFolder, err := canvasClient.GetCourseVideos(ctx, page, targetDir, c, func(isFile bool) {
 				incrementCount(courseSpinners[code], isFile)
 			})
+			if ctx.Err() != nil {
+				spc.sp.UpdateMessagef(pterm.FgYellow.Sprintf("Cancelled video download for %s", code))
+				spc.sp.Error()
+				return
+			}
 			if err != nil {
 				courseSpinners[code].sp.UpdateMessage(pterm.FgRed.Sprintf("No videos found for %s", code))
 				courseSpinners[code].sp.Error()
@@ -232,57 +267,31 @@ func RunPullVideos(cmd *cobra.Command, args []string, isUpdate bool) {
 			courseSpinners[code].sp.UpdateMessage(pterm.FgCyan.Sprintf("Downloading %d videos for %s...", len(files), c.CourseCode))
 
 			for _, fil := range filtered {
-				path := fil.Path
-				audioUrl := fil.AudioUrl
-				videoUrl := fil.VideoUrl
-
-				parent := filepath.Dir(path)
+				if ctx.Err() != nil {
+					break
+				}
+				parent := filepath.Dir(fil.Path)
 				if err := os.MkdirAll(parent, 0755); err != nil {
-					pterm.Error.Printf("failed to create parent directory for file %s, skipping", path)
+					pterm.Error.Printf("failed to create parent directory for file %s, skipping", fil.Path)
 				}
 
-				if videoUrl == "" {
-					// if only 1 source file, simply download
-					for i := 0; i < MAX_DOWNLOAD_ATTEMPTS; i++ {
-						err = ffmpeg_go.
-							Input(audioUrl).
-							Output(path, ffmpeg_go.KwArgs{"c": "copy"}).
-							Silent(true).
-							OverWriteOutput().
-							WithOutput(io.Discard).
-							Run()
-						if err == nil {
-							break
-						}
+				if err := downloadVideo(ctx, fil); err != nil {
+					if ctx.Err() == nil {
+						pterm.Error.Printfln("Error downloading video %s: %s", fil.Path, err.Error())
 					}
-				} else {
-					// if 2 source files, merge audio of audio file into video files
-					main := ffmpeg_go.Input(videoUrl)
-					overlay := ffmpeg_go.Input(audioUrl)
-					for i := 0; i < MAX_DOWNLOAD_ATTEMPTS; i++ {
-						err = ffmpeg_go.Output(
-							[]*ffmpeg_go.Stream{main, overlay},
-							path,
-							ffmpeg_go.KwArgs{"map": "1:a,0:v", "c:v": "copy"},
-						).
-							Silent(true).
-							OverWriteOutput().
-							WithOutput(io.Discard).
-							Run()
-						if err == nil {
-							break
-						}
-					}
-				}
-
-				if err != nil {
-					pterm.Error.Printfln("Error downloading video %s: %s", path, err.Error())
+					continue
 				}
+				downloadedVideos.Add(1)
 
 				spc.fileCount += 1
 				spc.sp.UpdateMessagef(pterm.FgCyan.Sprintf("Downloaded %d/%d videos for %s", spc.fileCount, len(filtered), code))
 			}
 
+			if ctx.Err() != nil {
+				spc.sp.UpdateMessagef(pterm.FgYellow.Sprintf("Cancelled video download for %s", code))
+				spc.sp.Error()
+				return
+			}
 			spc.sp.UpdateMessagef(pterm.FgGreen.Sprintf("Downloaded %d videos for %s", len(filtered), code))
 			spc.sp.Complete()
 		}(course, courseSpinners[course.CourseCode])
@@ -294,5 +303,9 @@ func RunPullVideos(cmd *cobra.Command, args []string, isUpdate bool) {
 	}
 
 	pterm.Println()
+	if ctx.Err() != nil {
+		pterm.Warning.Printfln("Interrupted, %d video(s) finished downloading before stopping (partial downloads removed)", downloadedVideos.Load())
+		os.Exit(canvas.EXIT_INTERRUPTED)
+	}
 	pterm.Success.Printfln("Downloaded videos: %s", targetDir)
 }
@@ -0,0 +1,86 @@
+package trash
+
+import (
+	"os"
+
+	"github.com/aidanaden/canvas-sync/internal/pkg/trash"
+	"github.com/aidanaden/canvas-sync/internal/pkg/utils"
+	"github.com/pterm/pterm"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func RunTrashList(cmd *cobra.Command, args []string) {
+	tableData := pterm.TableData{
+		{"Course", "Trashed", "File", "Size"},
+	}
+	for _, course := range utils.GetCourseDirs(utils.GetCourseCodesFromArgs(args)) {
+		items, err := trash.List(course.Dir)
+		if err != nil {
+			pterm.Error.Printfln("Failed to list trash for %s: %s", course.Code, err.Error())
+			os.Exit(1)
+		}
+		for _, item := range items {
+			tableData = append(tableData, []string{course.Code, item.Date, item.Path, utils.FormatSize(item.Size)})
+		}
+	}
+	if len(tableData) == 1 {
+		pterm.Info.Printfln("Trash is empty")
+		return
+	}
+	pterm.Println()
+	if err := pterm.DefaultTable.WithHasHeader().WithData(tableData).Render(); err != nil {
+		pterm.Error.Printfln("Error rendering trash: %s", err.Error())
+		os.Exit(1)
+	}
+	pterm.Println()
+}
+
+func RunTrashRestore(cmd *cobra.Command, args []string) {
+	course := utils.GetCourseDirs(args[:1])[0]
+	items, err := trash.List(course.Dir)
+	if err != nil {
+		pterm.Error.Printfln("Failed to list trash for %s: %s", course.Code, err.Error())
+		os.Exit(1)
+	}
+	failed := false
+	for _, path := range args[1:] {
+		item, ok := trash.Find(items, path)
+		if !ok {
+			pterm.Error.Printfln("No trashed file %s found for %s, see 'canvas-sync trash list %s'", path, course.Code, course.Code)
+			failed = true
+			continue
+		}
+		restored, err := trash.Restore(course.Dir, item)
+		if err != nil {
+			pterm.Error.Printfln("Failed to restore %s: %s", item.Path, err.Error())
+			failed = true
+			continue
+		}
+		pterm.Success.Printfln("Restored %s", restored)
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
+
+func RunTrashEmpty(cmd *cobra.Command, args []string) {
+	courses := utils.GetCourseDirs(utils.GetCourseCodesFromArgs(args))
+	if !viper.GetBool("yes") {
+		confirm, err := pterm.DefaultInteractiveConfirm.Show("Permanently delete all trashed files?")
+		if err != nil {
+			pterm.Error.Printfln("Failed to get confirmation: %s", err.Error())
+			os.Exit(1)
+		}
+		if !confirm {
+			return
+		}
+	}
+	for _, course := range courses {
+		if err := trash.Empty(course.Dir); err != nil {
+			pterm.Error.Printfln("Failed to empty trash for %s: %s", course.Code, err.Error())
+			os.Exit(1)
+		}
+	}
+	pterm.Success.Printfln("Emptied trash")
+}
@@ -0,0 +1,11 @@
+package update
+
+import (
+	"github.com/aidanaden/canvas-sync/internal/app/documents"
+	"github.com/aidanaden/canvas-sync/internal/pkg/canvas"
+	"github.com/spf13/cobra"
+)
+
+func RunUpdatePages(cmd *cobra.Command, args []string) {
+	documents.Run(cmd, args, canvas.PagesSource, canvas.DocumentOptions{OnlyChanged: true})
+}
@@ -5,6 +5,7 @@ import (
 	"os"
 
 	"github.com/aidanaden/canvas-sync/internal/pkg/canvas"
+	"github.com/aidanaden/canvas-sync/internal/pkg/config"
 	"github.com/aidanaden/canvas-sync/internal/pkg/utils"
 	strip "github.com/grokify/html-strip-tags-go"
 	"github.com/pterm/pterm"
@@ -13,25 +14,26 @@ import (
 )
 
 func RunViewCourseAnnouncements(cmd *cobra.Command, args []string) {
+	ctx := cmd.Context()
 	accessToken := fmt.Sprintf("%v", viper.Get("access_token"))
 	courseCode := args[0]
 	canvasUrl := fmt.Sprintf("%v", viper.Get("canvas_url"))
-	canvasClient := canvas.NewClient(canvasUrl, accessToken)
+	canvasClient := canvas.NewClient(canvasUrl, accessToken, config.ClientOptions()...)
 	if accessToken == "" {
 		pterm.Error.Printfln("Invalid config, please run 'canvas-sync init'")
 		os.Exit(1)
 	}
 
-	courseAnnouncements, err := canvasClient.GetCourseAnnouncements(courseCode)
+	courseAnnouncements, err := canvasClient.GetCourseAnnouncements(ctx, courseCode)
 	if err != nil {
-		pterm.Error.Printfln("Failed to fetch all course announcements: %s", err.Error())
+		canvas.ExitOnError("Failed to fetch all course announcements", err)
 	}
 
 	tableData := pterm.TableData{
 		{"Title", "Posted", "Author", "Message"},
 	}
 
-	for i := len(courseAnnouncements) - 1; i > 0; i-- {
+	for i := len(courseAnnouncements) - 1; i >= 0; i-- {
 		announcement := courseAnnouncements[i]
 		postedAtStr := utils.FormatEventDate(announcement.PostedAt)
 		tableData = append(tableData, []string{
@@ -6,6 +6,7 @@ import (
 	"strconv"
 
 	"github.com/aidanaden/canvas-sync/internal/pkg/canvas"
+	"github.com/aidanaden/canvas-sync/internal/pkg/config"
 	"github.com/aidanaden/canvas-sync/internal/pkg/nodes"
 	"github.com/aidanaden/canvas-sync/internal/pkg/utils"
 	"github.com/pterm/pterm"
@@ -14,32 +15,30 @@ import (
 )
 
 func RunViewDeadlines(cmd *cobra.Command, args []string, isPast bool) {
+	ctx := cmd.Context()
 	accessToken := fmt.Sprintf("%v", viper.Get("access_token"))
 	canvasUrl := fmt.Sprintf("%v", viper.Get("canvas_url"))
-	canvasClient := canvas.NewClient(canvasUrl, accessToken)
+	canvasClient := canvas.NewClient(canvasUrl, accessToken, config.ClientOptions()...)
 	if accessToken == "" {
 		pterm.Error.Printfln("Invalid config, please run 'canvas-sync init'")
 		os.Exit(1)
 	}
 
-	courses, err := canvasClient.GetActiveEnrolledCourses()
+	courses, err := canvasClient.GetEnrolledCourses(ctx)
 	if err != nil {
-		pterm.Error.Printfln("Failed to get actively enrolled courses: %s", err.Error())
-		os.Exit(1)
+		canvas.ExitOnError("Failed to get enrolled courses", err)
 	}
 
 	var events []nodes.EventNode
 	if isPast {
-		events, err = canvasClient.GetRecentCalendarEvents()
+		events, err = canvasClient.GetRecentCalendarEvents(ctx)
 		if err != nil {
-			pterm.Error.Printfln("Failed to fetch all recent assignments: %s", err.Error())
-			os.Exit(1)
+			canvas.ExitOnError("Failed to fetch all recent assignments", err)
 		}
 	} else {
-		events, err = canvasClient.GetIncomingCalendarEvents()
+		events, err = canvasClient.GetIncomingCalendarEvents(ctx)
 		if err != nil {
-			pterm.Error.Printfln("Failed to fetch all upcoming assignments: %s", err.Error())
-			os.Exit(1)
+			canvas.ExitOnError("Failed to fetch all upcoming assignments", err)
 		}
 	}
 
@@ -5,25 +5,26 @@ import (
 	"os"
 
 	"github.com/aidanaden/canvas-sync/internal/pkg/canvas"
+	"github.com/aidanaden/canvas-sync/internal/pkg/config"
 	"github.com/pterm/pterm"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
 
 func RunViewCoursePeople(cmd *cobra.Command, args []string) {
+	ctx := cmd.Context()
 	accessToken := fmt.Sprintf("%v", viper.Get("access_token"))
 	courseCode := args[0]
 	canvasUrl := fmt.Sprintf("%v", viper.Get("canvas_url"))
-	canvasClient := canvas.NewClient(canvasUrl, accessToken)
+	canvasClient := canvas.NewClient(canvasUrl, accessToken, config.ClientOptions()...)
 	if accessToken == "" {
 		pterm.Error.Printfln("Invalid config, please run 'canvas-sync init'")
 		os.Exit(1)
 	}
 
-	coursePeople, err := canvasClient.GetCoursePeople(courseCode)
+	coursePeople, err := canvasClient.GetCoursePeople(ctx, courseCode)
 	if err != nil {
-		pterm.Error.Printfln("Failed to fetch people from %s: %s", courseCode, err.Error())
-		os.Exit(1)
+		canvas.ExitOnError(fmt.Sprintf("Failed to fetch people from %s", courseCode), err)
 	}
 
 	tableData := pterm.TableData{
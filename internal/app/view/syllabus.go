@@ -0,0 +1,41 @@
+package view
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/aidanaden/canvas-sync/internal/pkg/canvas"
+	"github.com/aidanaden/canvas-sync/internal/pkg/config"
+	"github.com/pterm/pterm"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func RunViewCourseSyllabus(cmd *cobra.Command, args []string) {
+	ctx := cmd.Context()
+	accessToken := fmt.Sprintf("%v", viper.Get("access_token"))
+	courseCode := args[0]
+	canvasUrl := fmt.Sprintf("%v", viper.Get("canvas_url"))
+	canvasClient := canvas.NewClient(canvasUrl, accessToken, config.ClientOptions()...)
+	if accessToken == "" {
+		pterm.Error.Printfln("Invalid config, please run 'canvas-sync init'")
+		os.Exit(1)
+	}
+
+	course, err := canvasClient.ResolveCourse(ctx, courseCode)
+	if err != nil {
+		canvas.ExitOnError("Failed to find course", err)
+	}
+	syllabus, err := canvasClient.SyllabusMarkdown(ctx, course.ID)
+	if err != nil {
+		canvas.ExitOnError(fmt.Sprintf("Failed to fetch syllabus of %s", courseCode), err)
+	}
+	if syllabus == "" {
+		pterm.Info.Printfln("%s has no syllabus", courseCode)
+		return
+	}
+
+	pterm.Println()
+	pterm.DefaultSection.Printfln("%s syllabus", course.Name)
+	pterm.Println(syllabus)
+}
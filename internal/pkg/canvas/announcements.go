@@ -0,0 +1,135 @@
+package canvas
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/aidanaden/canvas-sync/internal/pkg/manifest"
+	"github.com/aidanaden/canvas-sync/internal/pkg/mbox"
+	"github.com/aidanaden/canvas-sync/internal/pkg/nodes"
+	"github.com/aidanaden/canvas-sync/internal/pkg/richtext"
+)
+
+const (
+	ANNOUNCEMENTS_DIR = "announcements"
+	// name of the document in each announcement's folder
+	ANNOUNCEMENT_DOCUMENT        = "announcement"
+	ANNOUNCEMENT_ATTACHMENTS_DIR = "attachments"
+	ANNOUNCEMENTS_MBOX           = "announcements.mbox"
+)
+
+// AnnouncementsSource archives the course's announcements, one folder per announcement
+var AnnouncementsSource = DocumentSource{
+	Dir: ANNOUNCEMENTS_DIR,
+	Sync: func(ctx context.Context, c *CanvasClient, course nodes.CourseNode, courseDir string, m *manifest.Manifest, opts DocumentOptions, report *SyncReport) (int, error) {
+		return c.SyncCourseAnnouncements(ctx, course, courseDir, m, opts, report)
+	},
+}
+
+// SyncCourseAnnouncements writes every announcement of a course into
+// <courseDir>/announcements/<date> <title>/announcement.md (with front matter) and announcement.html, with its
+// attachments and inline images in attachments/. The date is in UTC so folders don't change with the timezone, and
+// folders of announcements retitled on canvas are renamed to match. With opts.Mbox, every announcement is also written into
+// announcements/announcements.mbox. Returns the number of announcements written.
+func (c *CanvasClient) SyncCourseAnnouncements(ctx context.Context, course nodes.CourseNode, courseDir string, m *manifest.Manifest, opts DocumentOptions, report *SyncReport) (int, error) {
+	announcements, err := c.GetCourseAnnouncementsByID(ctx, course.ID)
+	if err != nil {
+		return 0, err
+	}
+	dir := filepath.Join(courseDir, ANNOUNCEMENTS_DIR)
+	links := c.newCourseLinks(m, courseDir)
+	// mailboxes are read elsewhere, keep links pointing at canvas
+	absolute := (&courseLinks{canvasPath: c.canvasPath}).rewrite("")
+	synced := syncedDocuments(m, dir, ANNOUNCEMENT_DOCUMENT)
+	claim := claimNames()
+	sort.SliceStable(announcements, func(i, j int) bool {
+		if announcements[i].PostedAt.Equal(announcements[j].PostedAt) {
+			return announcements[i].ID < announcements[j].ID
+		}
+		return announcements[i].PostedAt.Before(announcements[j].PostedAt)
+	})
+	messages := make([]mbox.Message, 0, len(announcements))
+	written := 0
+	for _, announcement := range announcements {
+		if ctx.Err() != nil {
+			return written, ctx.Err()
+		}
+		folder := filepath.Join(dir, claim(fmt.Sprintf("%s %s", announcement.PostedAt.UTC().Format("2006-01-02"), announcement.Title), announcement.ID))
+		base := filepath.Join(folder, ANNOUNCEMENT_DOCUMENT)
+		htmlPath := base + ".html"
+		if entry, ok := synced[announcement.ID]; ok {
+			if oldFolder := filepath.Dir(m.LocalPath(&entry)); oldFolder != folder {
+				// retitled on canvas
+				moveDocumentFolder(m, oldFolder, folder, report)
+			}
+		}
+		files := append(c.linkedFiles(ctx, announcement.Message, links, report), announcement.Attachments...)
+		local := c.downloadAttachments(ctx, files, filepath.Join(folder, ANNOUNCEMENT_ATTACHMENTS_DIR), m, report)
+
+		body := announcement.Message + fileList(announcement.Attachments, folder, local)
+		frontMatter := [][2]string{
+			{"title", announcement.Title},
+			{"author", announcement.PosterName},
+			{"posted_at", announcement.PostedAt.Format(time.RFC3339)},
+			{"url", announcement.HtmlUrl},
+		}
+		hash, size, err := writeDocumentWithFrontMatter(base, frontMatter, announcement.Title, body, links.with(local))
+		if err != nil {
+			report.AddFailed(htmlPath, err)
+			continue
+		}
+		m.Put(htmlPath, manifest.Entry{
+			FileID:     announcement.ID,
+			Size:       size,
+			UpdatedAt:  announcement.PostedAt,
+			CanvasPath: fmt.Sprintf("announcements/%d", announcement.ID),
+			Hash:       hash,
+		})
+		report.AddDownloaded(htmlPath)
+		written++
+
+		if !opts.Mbox {
+			continue
+		}
+		text, err := richtext.ToMarkdown(announcement.Message, absolute)
+		if err != nil {
+			report.AddFailed(htmlPath, err)
+			continue
+		}
+		rewritten, err := richtext.RewriteLinks(announcement.Message, absolute)
+		if err != nil {
+			report.AddFailed(htmlPath, err)
+			continue
+		}
+		attachments := make([]string, 0, len(local))
+		for _, file := range files {
+			if localPath, ok := local[file.ID]; ok {
+				attachments = append(attachments, localPath)
+				delete(local, file.ID)
+			}
+		}
+		messages = append(messages, mbox.Message{
+			ID:          fmt.Sprintf("announcement-%d@%s", announcement.ID, c.canvasPath.Host),
+			FromName:    announcement.PosterName,
+			From:        "noreply@" + c.canvasPath.Host,
+			ToName:      course.Name,
+			To:          "noreply@" + c.canvasPath.Host,
+			Subject:     fmt.Sprintf("[%s] %s", course.CourseCode, announcement.Title),
+			Date:        announcement.PostedAt,
+			Text:        text + "\n" + announcement.HtmlUrl + "\n",
+			Html:        richtext.Document(announcement.Title, rewritten),
+			Attachments: attachments,
+		})
+	}
+	if opts.Mbox && len(messages) > 0 {
+		mboxPath := filepath.Join(dir, ANNOUNCEMENTS_MBOX)
+		if err := mbox.WriteFile(mboxPath, messages); err != nil {
+			return written, err
+		}
+		report.AddDownloaded(mboxPath)
+	}
+	return written, nil
+}
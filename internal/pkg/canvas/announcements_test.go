@@ -0,0 +1,52 @@
+package canvas
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/aidanaden/canvas-sync/internal/pkg/manifest"
+	"github.com/aidanaden/canvas-sync/internal/pkg/nodes"
+)
+
+func announcementsClient(t *testing.T, title *string) *CanvasClient {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v1/courses/1/discussion_topics", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `[{"id": 3, "title": %q, "posted_at": "2024-03-01T20:00:00Z", "message": "<p>Hello</p>"}]`, *title)
+	})
+	return newAPIClient(t, mux)
+}
+
+func TestSyncCourseAnnouncementsRenamesFolders(t *testing.T) {
+	title := "Welcome"
+	c := announcementsClient(t, &title)
+	courseDir := t.TempDir()
+	m, err := manifest.Load(courseDir, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := filepath.Join(courseDir, ANNOUNCEMENTS_DIR)
+
+	if _, err := c.SyncCourseAnnouncements(context.Background(), testCourse(), courseDir, m, DocumentOptions{}, &SyncReport{}); err != nil {
+		t.Fatal(err)
+	}
+	title = "Welcome to CS1010"
+	if _, err := c.SyncCourseAnnouncements(context.Background(), testCourse(), courseDir, m, DocumentOptions{}, &SyncReport{}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, "2024-03-01 Welcome")); !os.IsNotExist(err) {
+		t.Error("the folder under the old title was left behind")
+	}
+	htmlPath := filepath.Join(dir, "2024-03-01 Welcome to CS1010", ANNOUNCEMENT_DOCUMENT+".html")
+	if entry := m.Get(htmlPath); entry == nil || entry.FileID != 3 {
+		t.Errorf("manifest entry for %s is %v, want announcement 3", htmlPath, entry)
+	}
+}
+
+func testCourse() nodes.CourseNode {
+	return nodes.CourseNode{ID: 1, CourseCode: "CS1010", Name: "Programming Methodology"}
+}
@@ -0,0 +1,269 @@
+package canvas
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"html"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aidanaden/canvas-sync/internal/pkg/manifest"
+	"github.com/aidanaden/canvas-sync/internal/pkg/nodes"
+	"github.com/aidanaden/canvas-sync/internal/pkg/utils"
+)
+
+const (
+	ASSIGNMENTS_DIR = "assignments"
+	// name of the description document in each assignment's folder
+	ASSIGNMENT_DOCUMENT = "assignment"
+	// folders in each assignment's folder
+	ASSIGNMENT_ATTACHMENTS_DIR = "attachments"
+	ASSIGNMENT_SUBMISSION_DIR  = "submission"
+)
+
+func extractAssignmentsFromString(rawJson string) ([]nodes.AssignmentNode, error) {
+	var assignments []nodes.AssignmentNode
+	if err := json.Unmarshal([]byte(rawJson), &assignments); err != nil {
+		return nil, fmt.Errorf("failed to parse assignments: %w", err)
+	}
+	return assignments, nil
+}
+
+// GetCourseAssignments returns a course's assignments with the user's submission to each
+func (c *CanvasClient) GetCourseAssignments(ctx context.Context, courseId int) ([]nodes.AssignmentNode, error) {
+	assignmentsUrl := c.getCourseListUrl(courseId, "assignments")
+	q := assignmentsUrl.Query()
+	q.Add("include[]", "submission")
+	assignmentsUrl.RawQuery = q.Encode()
+	return getPaginated(ctx, c, assignmentsUrl, extractAssignmentsFromString)
+}
+
+// GetOwnSubmission returns the user's submission to an assignment with its comments and rubric assessment
+func (c *CanvasClient) GetOwnSubmission(ctx context.Context, courseId int, assignmentId int) (*nodes.SubmissionNode, error) {
+	submissionUrl := c.getCourseListUrl(courseId, fmt.Sprintf("assignments/%d/submissions/self", assignmentId))
+	q := submissionUrl.Query()
+	q.Add("include[]", "submission_comments")
+	q.Add("include[]", "rubric_assessment")
+	submissionUrl.RawQuery = q.Encode()
+	submissionJson, _, err := c.get(ctx, submissionUrl.String())
+	if err != nil {
+		return nil, err
+	}
+	var submission *nodes.SubmissionNode
+	if err := json.Unmarshal(submissionJson, &submission); err != nil {
+		return nil, fmt.Errorf("failed to parse submission: %w", err)
+	}
+	return submission, nil
+}
+
+// AssignmentsSource archives the course's assignments, one folder per assignment
+var AssignmentsSource = DocumentSource{
+	Dir: ASSIGNMENTS_DIR,
+	Sync: func(ctx context.Context, c *CanvasClient, course nodes.CourseNode, courseDir string, m *manifest.Manifest, opts DocumentOptions, report *SyncReport) (int, error) {
+		return c.SyncCourseAssignments(ctx, course.ID, courseDir, m, report)
+	},
+}
+
+// SyncCourseAssignments writes every assignment of a course into <courseDir>/assignments/<name>/: its description,
+// dates, points and rubric as assignment.html and assignment.md, the files linked from the description into
+// attachments/, and the user's submitted files and comment attachments into submission/. Folders of assignments
+// renamed on canvas are renamed to match. Returns the number of assignments written.
+func (c *CanvasClient) SyncCourseAssignments(ctx context.Context, courseId int, courseDir string, m *manifest.Manifest, report *SyncReport) (int, error) {
+	assignments, err := c.GetCourseAssignments(ctx, courseId)
+	if err != nil {
+		return 0, err
+	}
+	dir := filepath.Join(courseDir, ASSIGNMENTS_DIR)
+	links := c.newCourseLinks(m, courseDir)
+	synced := syncedDocuments(m, dir, ASSIGNMENT_DOCUMENT)
+	claim := claimNames()
+	sort.SliceStable(assignments, func(i, j int) bool {
+		return assignments[i].ID < assignments[j].ID
+	})
+	written := 0
+	for _, assignment := range assignments {
+		if ctx.Err() != nil {
+			return written, ctx.Err()
+		}
+		folder := filepath.Join(dir, claim(assignment.Name, assignment.ID))
+		base := filepath.Join(folder, ASSIGNMENT_DOCUMENT)
+		htmlPath := base + ".html"
+		if assignment.LockedForUser && assignment.Description == "" {
+			var unlockAt *time.Time
+			if assignment.LockInfo != nil {
+				unlockAt = assignment.LockInfo.UnlockAt
+			}
+			report.AddLocked(folder, unlockAt)
+			continue
+		}
+		if entry, ok := synced[assignment.ID]; ok {
+			if oldFolder := filepath.Dir(m.LocalPath(&entry)); oldFolder != folder {
+				// renamed on canvas
+				moveDocumentFolder(m, oldFolder, folder, report)
+			}
+		}
+
+		submission := assignment.Submission
+		if submission != nil && (submission.SubmittedAt != nil || submission.WorkflowState == "graded") {
+			full, err := c.GetOwnSubmission(ctx, courseId, assignment.ID)
+			if err != nil {
+				report.AddFailed(htmlPath, err)
+			} else {
+				submission = full
+			}
+		}
+
+		local := c.downloadAttachments(ctx, c.linkedFiles(ctx, assignment.Description, links, report), filepath.Join(folder, ASSIGNMENT_ATTACHMENTS_DIR), m, report)
+		var submitted map[int]string
+		if submission != nil {
+			files := append([]*nodes.FileNode{}, submission.Attachments...)
+			for _, comment := range submission.SubmissionComments {
+				files = append(files, comment.Attachments...)
+			}
+			submitted = c.downloadAttachments(ctx, files, filepath.Join(folder, ASSIGNMENT_SUBMISSION_DIR), m, report)
+			for id, localPath := range submitted {
+				local[id] = localPath
+			}
+		}
+
+		body := assignmentBody(assignment, submission, folder, submitted)
+		hash, size, err := writeDocument(base, assignment.Name, body, links.with(local))
+		if err != nil {
+			report.AddFailed(htmlPath, err)
+			continue
+		}
+		m.Put(htmlPath, manifest.Entry{
+			FileID:     assignment.ID,
+			Size:       size,
+			UpdatedAt:  assignment.UpdatedAt,
+			CanvasPath: fmt.Sprintf("assignments/%d", assignment.ID),
+			Hash:       hash,
+		})
+		report.AddDownloaded(htmlPath)
+		written++
+	}
+	return written, nil
+}
+
+func formatPoints(points float64) string {
+	return strconv.FormatFloat(points, 'f', -1, 64)
+}
+
+func formatDate(t *time.Time) string {
+	if t == nil {
+		return "-"
+	}
+	return utils.FormatEventDate(t.Local())
+}
+
+// fileList links the files (by id) from a document in folder, "" if none were downloaded
+func fileList(files []*nodes.FileNode, folder string, local map[int]string) string {
+	var b strings.Builder
+	for _, file := range files {
+		if localPath, ok := local[file.ID]; ok {
+			fmt.Fprintf(&b, "<li><a href=\"%s\">%s</a></li>", html.EscapeString(markdownLink(folder, localPath)), html.EscapeString(file.Display_name))
+		}
+	}
+	if b.Len() == 0 {
+		return ""
+	}
+	return "<ul>" + b.String() + "</ul>"
+}
+
+// assignmentBody renders an assignment's details, description, rubric, submission and comments as html
+func assignmentBody(assignment nodes.AssignmentNode, submission *nodes.SubmissionNode, folder string, submitted map[int]string) string {
+	var b strings.Builder
+	b.WriteString("<ul>")
+	fmt.Fprintf(&b, "<li><strong>Due:</strong> %s</li>", html.EscapeString(formatDate(assignment.DueAt)))
+	fmt.Fprintf(&b, "<li><strong>Available from:</strong> %s</li>", html.EscapeString(formatDate(assignment.UnlockAt)))
+	fmt.Fprintf(&b, "<li><strong>Available until:</strong> %s</li>", html.EscapeString(formatDate(assignment.LockAt)))
+	fmt.Fprintf(&b, "<li><strong>Points:</strong> %s</li>", formatPoints(assignment.PointsPossible))
+	if len(assignment.SubmissionTypes) > 0 {
+		fmt.Fprintf(&b, "<li><strong>Submission types:</strong> %s</li>", html.EscapeString(strings.Join(assignment.SubmissionTypes, ", ")))
+	}
+	fmt.Fprintf(&b, "<li><strong>Canvas:</strong> <a href=\"%s\">%s</a></li>", html.EscapeString(assignment.HtmlUrl), html.EscapeString(assignment.HtmlUrl))
+	b.WriteString("</ul>")
+
+	b.WriteString("<h2>Description</h2>")
+	if assignment.Description != "" {
+		b.WriteString(assignment.Description)
+	} else {
+		b.WriteString("<p>No description</p>")
+	}
+
+	if len(assignment.Rubric) > 0 {
+		b.WriteString("<h2>Rubric</h2><table><tr><th>Criterion</th><th>Ratings</th><th>Points</th>")
+		assessed := submission != nil && len(submission.RubricAssessment) > 0
+		if assessed {
+			b.WriteString("<th>Your points</th><th>Comments</th>")
+		}
+		b.WriteString("</tr>")
+		for _, criterion := range assignment.Rubric {
+			ratings := make([]string, 0, len(criterion.Ratings))
+			for _, rating := range criterion.Ratings {
+				ratings = append(ratings, fmt.Sprintf("%s (%s)", rating.Description, formatPoints(rating.Points)))
+			}
+			description := criterion.Description
+			if criterion.LongDescription != "" {
+				description += ": " + criterion.LongDescription
+			}
+			fmt.Fprintf(&b, "<tr><td>%s</td><td>%s</td><td>%s</td>", html.EscapeString(description), html.EscapeString(strings.Join(ratings, "; ")), formatPoints(criterion.Points))
+			if assessed {
+				assessment := submission.RubricAssessment[criterion.ID]
+				points := "-"
+				if assessment.Points != nil {
+					points = formatPoints(*assessment.Points)
+				}
+				fmt.Fprintf(&b, "<td>%s</td><td>%s</td>", points, html.EscapeString(assessment.Comments))
+			}
+			b.WriteString("</tr>")
+		}
+		b.WriteString("</table>")
+	}
+
+	if submission == nil || (submission.SubmittedAt == nil && submission.Score == nil && len(submission.SubmissionComments) == 0) {
+		return b.String()
+	}
+	b.WriteString("<h2>Submission</h2><ul>")
+	fmt.Fprintf(&b, "<li><strong>Submitted:</strong> %s</li>", html.EscapeString(formatDate(submission.SubmittedAt)))
+	if submission.Attempt > 0 {
+		fmt.Fprintf(&b, "<li><strong>Attempt:</strong> %d</li>", submission.Attempt)
+	}
+	if submission.Late {
+		b.WriteString("<li><strong>Late</strong></li>")
+	}
+	if submission.Score != nil {
+		fmt.Fprintf(&b, "<li><strong>Score:</strong> %s / %s</li>", formatPoints(*submission.Score), formatPoints(assignment.PointsPossible))
+	}
+	if submission.Grade != "" {
+		fmt.Fprintf(&b, "<li><strong>Grade:</strong> %s</li>", html.EscapeString(submission.Grade))
+	}
+	if submission.Url != "" {
+		fmt.Fprintf(&b, "<li><strong>Url:</strong> <a href=\"%s\">%s</a></li>", html.EscapeString(submission.Url), html.EscapeString(submission.Url))
+	}
+	b.WriteString("</ul>")
+	b.WriteString(fileList(submission.Attachments, folder, submitted))
+	if submission.Body != "" {
+		b.WriteString("<h3>Text entry</h3>")
+		b.WriteString(submission.Body)
+	}
+
+	if len(submission.SubmissionComments) > 0 {
+		b.WriteString("<h2>Comments</h2>")
+		for _, comment := range submission.SubmissionComments {
+			fmt.Fprintf(&b, "<blockquote><p><strong>%s</strong>, %s</p>", html.EscapeString(comment.AuthorName), html.EscapeString(utils.FormatEventDate(comment.CreatedAt.Local())))
+			for _, paragraph := range strings.Split(comment.Comment, "\n") {
+				if strings.TrimSpace(paragraph) != "" {
+					fmt.Fprintf(&b, "<p>%s</p>", html.EscapeString(paragraph))
+				}
+			}
+			b.WriteString(fileList(comment.Attachments, folder, submitted))
+			b.WriteString("</blockquote>")
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,50 @@
+package canvas
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/aidanaden/canvas-sync/internal/pkg/manifest"
+)
+
+func TestSyncCourseAssignmentsRenamesFolders(t *testing.T) {
+	name := "HW 1"
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v1/courses/1/assignments", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `[{"id": 7, "name": %q, "description": "<p>Do it</p>", "updated_at": "2024-03-01T10:00:00Z"}]`, name)
+	})
+	c := newAPIClient(t, mux)
+	courseDir := t.TempDir()
+	m, err := manifest.Load(courseDir, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := filepath.Join(courseDir, ASSIGNMENTS_DIR)
+
+	if _, err := c.SyncCourseAssignments(context.Background(), 1, courseDir, m, &SyncReport{}); err != nil {
+		t.Fatal(err)
+	}
+	name = "Homework 1"
+	report := &SyncReport{}
+	if _, err := c.SyncCourseAssignments(context.Background(), 1, courseDir, m, report); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, "HW 1")); !os.IsNotExist(err) {
+		t.Error("the folder under the old name was left behind")
+	}
+	htmlPath := filepath.Join(dir, "Homework 1", ASSIGNMENT_DOCUMENT+".html")
+	if !documentExists(htmlPath) {
+		t.Errorf("%s wasn't written", htmlPath)
+	}
+	if entry := m.Get(htmlPath); entry == nil || entry.FileID != 7 {
+		t.Errorf("manifest entry for %s is %v, want assignment 7", htmlPath, entry)
+	}
+	if len(report.Moved) != 1 {
+		t.Errorf("reported moves %v, want the renamed folder", report.Moved)
+	}
+}
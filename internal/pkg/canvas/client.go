@@ -2,6 +2,8 @@ package canvas
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -11,11 +13,13 @@ import (
 	"os"
 	"path/filepath"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
 	"time"
 
+	"github.com/aidanaden/canvas-sync/internal/pkg/manifest"
 	"github.com/aidanaden/canvas-sync/internal/pkg/nodes"
 	"github.com/aidanaden/canvas-sync/internal/pkg/utils"
 	"github.com/playwright-community/playwright-go"
@@ -25,14 +29,79 @@ import (
 
 const apiPath = "/api/v1"
 
+// suffix of files that are still downloading
+const PART_SUFFIX = ".part"
+
 type CanvasClient struct {
-	client      *http.Client
-	canvasPath  *url.URL
-	apiPath     *url.URL
-	accessToken string
+	client       *http.Client
+	canvasPath   *url.URL
+	apiPath      *url.URL
+	accessToken  string
+	retryPolicy  RetryPolicy
+	courseFilter CourseFilter
+	coursesMu    sync.Mutex
+	courses      []nodes.CourseNode
+	scheduler    *scheduler
+	dataDir      string
+}
+
+type clientOptions struct {
+	maxConcurrency int
+	retryPolicy    RetryPolicy
+	courseFilter   CourseFilter
+	workers        int
+	priority       Priority
+	dataDir        string
 }
 
-func NewClient(rawUrl string, accessToken string) *CanvasClient {
+type ClientOption func(*clientOptions)
+
+// WithMaxConcurrency limits the number of requests made by the client awaiting a response, unlimited if n <= 0
+func WithMaxConcurrency(n int) ClientOption {
+	return func(o *clientOptions) {
+		o.maxConcurrency = n
+	}
+}
+
+// WithRetryPolicy sets how failed api requests and file downloads are retried
+func WithRetryPolicy(policy RetryPolicy) ClientOption {
+	return func(o *clientOptions) {
+		o.retryPolicy = policy
+	}
+}
+
+// WithCourseFilter sets which enrolled courses are returned by GetEnrolledCourses
+func WithCourseFilter(filter CourseFilter) ClientOption {
+	return func(o *clientOptions) {
+		o.courseFilter = filter
+	}
+}
+
+// WithScheduler sets the number of file download workers shared by all courses and which files each course downloads first
+func WithScheduler(workers int, priority Priority) ClientOption {
+	return func(o *clientOptions) {
+		o.workers = workers
+		o.priority = priority
+	}
+}
+
+// WithDataDir sets the directory courses are downloaded into, so each enrolled course gets a directory of its own
+func WithDataDir(dir string) ClientOption {
+	return func(o *clientOptions) {
+		o.dataDir = dir
+	}
+}
+
+func NewClient(rawUrl string, accessToken string, opts ...ClientOption) *CanvasClient {
+	options := clientOptions{
+		retryPolicy: DefaultRetryPolicy,
+		workers:     DEFAULT_WORKERS,
+		priority:    PRIORITY_DEFAULT,
+	}
+	for _, opt := range opts {
+		opt(&options)
+	}
+
 	schemas := []string{"http://", "https://"}
 	canvasHost := ""
 	for _, schema := range schemas {
@@ -54,55 +123,194 @@ func NewClient(rawUrl string, accessToken string) *CanvasClient {
 		Host:   canvasPath.Host,
 		Path:   apiPath,
 	}
-	httpClient := http.Client{}
+	httpClient := http.Client{
+		Transport: newRateLimitTransport(http.DefaultTransport, options.maxConcurrency),
+	}
 	return &CanvasClient{
-		client:      &httpClient,
-		accessToken: accessToken,
-		canvasPath:  &canvasPath,
-		apiPath:     &apiPath,
+		client:       &httpClient,
+		accessToken:  accessToken,
+		canvasPath:   &canvasPath,
+		apiPath:      &apiPath,
+		retryPolicy:  options.retryPolicy,
+		courseFilter: options.courseFilter,
+		scheduler:    newScheduler(options.workers, options.priority),
+		dataDir:      options.dataDir,
 	}
 }
 
-func (c *CanvasClient) GetActiveEnrolledCoursesURL() url.URL {
+// Close stops the client's download workers, dropping any downloads still queued
+func (c *CanvasClient) Close() {
+	c.scheduler.close()
+}
+
+// newRequest creates a request authorised via the Authorization header, only set for the canvas host
+// so the token is never sent to the file storage hosts that downloads redirect to
+func (c *CanvasClient) newRequest(ctx context.Context, method string, rawUrl string) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, rawUrl, nil)
+	if err != nil {
+		return nil, err
+	}
+	if req.URL.Host == c.canvasPath.Host {
+		utils.SetAuthorizationHeader(req, c.accessToken)
+	}
+	return req, nil
+}
+
+// get performs an authenticated GET request with retries, returning the response body or an *APIError for non-2xx responses
+func (c *CanvasClient) get(ctx context.Context, rawUrl string) ([]byte, http.Header, error) {
+	var body []byte
+	var header http.Header
+	err := c.withRetry(ctx, func() error {
+		var err error
+		body, header, err = c.getOnce(ctx, rawUrl)
+		return err
+	})
+	return body, header, err
+}
+
+func (c *CanvasClient) getOnce(ctx context.Context, rawUrl string) ([]byte, http.Header, error) {
+	req, err := c.newRequest(ctx, "GET", rawUrl)
+	if err != nil {
+		return nil, nil, err
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, resp.Header, newAPIError(resp, body)
+	}
+	return body, resp.Header, nil
+}
+
+// CourseFilter selects which enrolled courses are returned, defaults to only actively enrolled courses
+type CourseFilter struct {
+	// also include courses from concluded enrollments e.g. past semesters
+	IncludeCompleted bool
+	// include courses regardless of enrollment state (active, invited/pending and completed)
+	AllEnrollments bool
+	// only include courses whose term name contains this (case-insensitive)
+	Term string
+}
+
+func (f CourseFilter) enrollmentStates() []string {
+	if f.AllEnrollments {
+		return []string{"active", "invited_or_pending", "completed"}
+	}
+	if f.IncludeCompleted {
+		return []string{"active", "completed"}
+	}
+	return []string{"active"}
+}
+
+func (c *CanvasClient) GetEnrolledCoursesURL(enrollmentState string) url.URL {
 	return url.URL{
 		Scheme: c.apiPath.Scheme,
 		Host:   c.apiPath.Host,
 		Path:   c.apiPath.Path + "/users/self/courses",
 		RawQuery: url.Values{
-			"enrollment_state": {"active"},
+			"enrollment_state": {enrollmentState},
+			"include[]":        {"term"},
 		}.Encode(),
 	}
 }
 
-func (c *CanvasClient) GetActiveEnrolledCourses() ([]nodes.CourseNode, error) {
-	coursesUrl := c.GetActiveEnrolledCoursesURL()
-	// courses request
-	req, err := http.NewRequest("GET", coursesUrl.String(), nil)
-	utils.SetQueryAccessToken(req, c.accessToken)
-	if err != nil {
-		return nil, err
+func extractCoursesFromString(rawJson string) ([]nodes.CourseNode, error) {
+	var courses []nodes.CourseNode
+	if err := json.Unmarshal([]byte(rawJson), &courses); err != nil {
+		return nil, fmt.Errorf("failed to parse courses: %w", err)
 	}
+	return courses, nil
+}
 
-	resp, err := c.client.Do(req)
-	if err != nil {
-		return nil, err
+// GetEnrolledCourses returns all enrolled courses matching the client's course filter, cached for the client's lifetime
+func (c *CanvasClient) GetEnrolledCourses(ctx context.Context) ([]nodes.CourseNode, error) {
+	c.coursesMu.Lock()
+	defer c.coursesMu.Unlock()
+	if c.courses != nil {
+		return c.courses, nil
 	}
 
-	courseJson := utils.ExtractResponseToString(resp)
-	var courses []nodes.CourseNode
-	json.Unmarshal([]byte(courseJson), &courses)
-	if strings.Contains(courseJson, "user authorisation required") {
-		return nil, fmt.Errorf("existing config invalid, please run 'canvas-sync init'")
+	courses := make([]nodes.CourseNode, 0)
+	seen := make(map[int]bool)
+	for _, state := range c.courseFilter.enrollmentStates() {
+		stateCourses, err := getPaginated(ctx, c, c.GetEnrolledCoursesURL(state), extractCoursesFromString)
+		if err != nil {
+			return nil, err
+		}
+		for _, course := range stateCourses {
+			// courses can have enrollments in multiple states
+			if seen[course.ID] {
+				continue
+			}
+			seen[course.ID] = true
+			if c.courseFilter.Term != "" && (course.Term == nil || !strings.Contains(strings.ToLower(course.Term.Name), strings.ToLower(c.courseFilter.Term))) {
+				continue
+			}
+			courses = append(courses, course)
+		}
 	}
 	re := regexp.MustCompile("[^a-zA-Z0-9-]")
 	for i := range courses {
+		courses[i].OriginalCourseCode = courses[i].CourseCode
 		code := strings.ReplaceAll(courses[i].CourseCode, "/", "-")
 		code = re.ReplaceAllString(code, "")
 		courses[i].CourseCode = code
 	}
+	uniqueCourseCodes(c.dataDir, courses)
+	c.courses = courses
 	return courses, nil
 }
 
+// uniqueCourseCodes gives every course a code of its own, as the code names the course's directory under dataDir.
+// Past offerings of a course (or codes that only differ in stripped characters) share a code: a course keeps the plain
+// code if it already downloaded into it (by the course id in the directory's sync manifest) or the directory is
+// unclaimed, otherwise its canvas id is appended e.g. "CS1010-1234". Directories from earlier runs are kept, so a
+// course's directory doesn't change between runs.
+func uniqueCourseCodes(dataDir string, courses []nodes.CourseNode) {
+	codes := make([]string, len(courses))
+	claimed := make(map[string]bool)
+	claim := func(i int, code string) {
+		codes[i] = code
+		claimed[strings.ToLower(code)] = true
+	}
+	suffixed := func(i int) string {
+		return fmt.Sprintf("%s-%d", courses[i].CourseCode, courses[i].ID)
+	}
+	// directories courses already downloaded into come first
+	if dataDir != "" {
+		for i := range courses {
+			if _, err := os.Stat(filepath.Join(dataDir, suffixed(i))); err == nil {
+				claim(i, suffixed(i))
+			} else if owner, err := manifest.CourseID(filepath.Join(dataDir, courses[i].CourseCode)); err == nil && owner == courses[i].ID {
+				claim(i, courses[i].CourseCode)
+			}
+		}
+	}
+	for i := range courses {
+		if codes[i] != "" {
+			continue
+		}
+		code := courses[i].CourseCode
+		if claimed[strings.ToLower(code)] {
+			code = suffixed(i)
+		} else if dataDir != "" {
+			if owner, err := manifest.CourseID(filepath.Join(dataDir, code)); err != nil || owner != 0 {
+				code = suffixed(i)
+			}
+		}
+		claim(i, code)
+	}
+	for i := range courses {
+		courses[i].CourseCode = codes[i]
+	}
+}
+
 func (c *CanvasClient) getCourseUrl(id int) url.URL {
 	courseUrl := url.URL{
 		Scheme: c.apiPath.Scheme,
@@ -112,28 +320,37 @@ func (c *CanvasClient) getCourseUrl(id int) url.URL {
 	return courseUrl
 }
 
-func (c *CanvasClient) GetCourseRootFolder(courseId int) (*nodes.DirectoryNode, error) {
+func (c *CanvasClient) GetCourseRootFolder(ctx context.Context, courseId int) (*nodes.DirectoryNode, error) {
 	courseUrl := c.getCourseUrl(courseId)
-	req, err := http.NewRequest("GET", courseUrl.String(), nil)
-	utils.SetQueryAccessToken(req, c.accessToken)
-	if err != nil {
-		return nil, err
-	}
-	rootRes, err := c.client.Do(req)
+	rootJson, _, err := c.get(ctx, courseUrl.String())
 	if err != nil {
 		return nil, err
 	}
-	rootJson := utils.ExtractResponseToString(rootRes)
 	var rootNode *nodes.DirectoryNode
-	if err := json.Unmarshal([]byte(rootJson), &rootNode); err != nil {
-		return nil, err
+	if err := json.Unmarshal(rootJson, &rootNode); err != nil {
+		return nil, fmt.Errorf("failed to parse root folder: %w", err)
 	}
 	return rootNode, nil
 }
 
-var PER_PAGE = 100
+func extractFilesFromString(rawJson string) ([]*nodes.FileNode, error) {
+	var files []*nodes.FileNode
+	if err := json.Unmarshal([]byte(rawJson), &files); err != nil {
+		return nil, fmt.Errorf("failed to parse files: %w", err)
+	}
+	return files, nil
+}
+
+func extractFoldersFromString(rawJson string) ([]*nodes.DirectoryNode, error) {
+	var folders []*nodes.DirectoryNode
+	if err := json.Unmarshal([]byte(rawJson), &folders); err != nil {
+		return nil, fmt.Errorf("failed to parse folders: %w", err)
+	}
+	return folders, nil
+}
 
-func (c *CanvasClient) RecurseDirectoryNode(node *nodes.DirectoryNode, parent *nodes.DirectoryNode) error {
+// RecurseDirectoryNode fetches the files and folders under node, skipping folders excluded by filter
+func (c *CanvasClient) RecurseDirectoryNode(ctx context.Context, node *nodes.DirectoryNode, parent *nodes.DirectoryNode, filter FileFilter) error {
 	dir := ""
 	if parent != nil {
 		dir = filepath.Join(parent.Directory)
@@ -149,37 +366,9 @@ func (c *CanvasClient) RecurseDirectoryNode(node *nodes.DirectoryNode, parent *n
 		if err != nil {
 			return err
 		}
-
-		var allFiles []*nodes.FileNode
-		page := 0
-		for {
-			page += 1
-			nolimitFileUrl := url.URL{
-				Scheme: parsedFileUrl.Scheme,
-				Host:   parsedFileUrl.Host,
-				Path:   parsedFileUrl.Path,
-				RawQuery: url.Values{
-					"page":     {strconv.Itoa(page)},
-					"per_page": {strconv.Itoa(PER_PAGE)},
-				}.Encode(),
-			}
-			fileReq, err := http.NewRequest("GET", nolimitFileUrl.String(), nil)
-			if err != nil {
-				return err
-			}
-			utils.SetQueryAccessToken(fileReq, c.accessToken)
-			filesRes, err := c.client.Do(fileReq)
-			if err != nil {
-				return err
-			}
-			filesJson := utils.ExtractResponseToString(filesRes)
-			var files []*nodes.FileNode
-			json.Unmarshal([]byte(filesJson), &files)
-			allFiles = append(allFiles, files...)
-			// break if less than 100 files, otherwise query next page
-			if len(files) < PER_PAGE {
-				break
-			}
+		allFiles, err := getPaginated(ctx, c, *parsedFileUrl, extractFilesFromString)
+		if err != nil {
+			return err
 		}
 		for f := range allFiles {
 			allFiles[f].Directory = filepath.Join(dir, allFiles[f].Display_name)
@@ -192,175 +381,363 @@ func (c *CanvasClient) RecurseDirectoryNode(node *nodes.DirectoryNode, parent *n
 		if err != nil {
 			return err
 		}
-
-		var allFolders []*nodes.DirectoryNode
-		page := 0
-		for {
-			page += 1
-			nolimitFolderUrl := url.URL{
-				Scheme: parsedFolderUrl.Scheme,
-				Host:   parsedFolderUrl.Host,
-				Path:   parsedFolderUrl.Path,
-				RawQuery: url.Values{
-					"page":     {strconv.Itoa(page)},
-					"per_page": {strconv.Itoa(PER_PAGE)},
-				}.Encode(),
-			}
-			folderReq, err := http.NewRequest("GET", nolimitFolderUrl.String(), nil)
-			if err != nil {
-				return err
-			}
-			utils.SetQueryAccessToken(folderReq, c.accessToken)
-			foldersRes, err := c.client.Do(folderReq)
-			if err != nil {
-				return err
-			}
-			foldersJson := utils.ExtractResponseToString(foldersRes)
-			var folders []*nodes.DirectoryNode
-			json.Unmarshal([]byte(foldersJson), &folders)
-			allFolders = append(allFolders, folders...)
-			// break if less than 100 folders, otherwise query next page
-			if len(folders) < PER_PAGE {
-				break
-			}
+		allFolders, err := getPaginated(ctx, c, *parsedFolderUrl, extractFoldersFromString)
+		if err != nil {
+			return err
 		}
+		folders := make([]*nodes.DirectoryNode, 0, len(allFolders))
 		for fi := range allFolders {
-			if err := c.RecurseDirectoryNode(allFolders[fi], node); err != nil {
+			if filter.skipsFolder(folderCanvasPath(allFolders[fi])) {
+				continue
+			}
+			// listing a locked folder fails, it's reported when syncing
+			if folderLocked(allFolders[fi]) {
+				folders = append(folders, allFolders[fi])
+				continue
+			}
+			if err := c.RecurseDirectoryNode(ctx, allFolders[fi], node, filter); err != nil {
 				return err
 			}
+			folders = append(folders, allFolders[fi])
 		}
-		node.FolderNodes = allFolders
+		node.FolderNodes = folders
 	}
 
 	return nil
 }
 
-func (c *CanvasClient) downloadFileNode(node *nodes.FileNode) error {
+// downloadFileNode downloads a file with retries, recording it in the manifest once complete
+func (c *CanvasClient) downloadFileNode(ctx context.Context, node *nodes.FileNode, m *manifest.Manifest) error {
 	if node == nil {
 		return errors.New("cannot download file without file node")
 	}
-	file, err := os.Create(node.Directory)
+	var hash string
+	var size int64
+	err := c.withRetry(ctx, func() error {
+		var err error
+		hash, size, err = c.downloadFileNodeOnce(ctx, node)
+		return err
+	})
 	if err != nil {
+		// keep partial downloads that can be resumed on the next run
+		if ctx.Err() == nil && !c.retryPolicy.shouldRetry(err) {
+			removePart(node)
+		}
 		return err
 	}
-	defer file.Close()
-	res, err := c.client.Get(node.Url)
+	m.Put(node.Directory, manifest.Entry{
+		FileID:     node.ID,
+		FolderID:   node.FolderID,
+		Size:       size,
+		UpdatedAt:  node.UpdatedAt,
+		CanvasPath: node.CanvasPath,
+		Hash:       hash,
+	})
+	return nil
+}
+
+// partPath is where a file is downloaded to before being renamed into place
+func partPath(node *nodes.FileNode) string {
+	return node.Directory + PART_SUFFIX
+}
+
+// partInfo identifies the remote file a .part file holds the start of, so it's only resumed if that file is unchanged
+type partInfo struct {
+	UpdatedAt time.Time `json:"updated_at"`
+	// validator sent as If-Range, the strong ETag or Last-Modified of the response the part was started from
+	Validator string `json:"validator"`
+}
+
+// partInfoPath is where the partInfo of a .part file is kept, hidden next to it
+func partInfoPath(node *nodes.FileNode) string {
+	return filepath.Join(filepath.Dir(node.Directory), "."+filepath.Base(node.Directory)+PART_SUFFIX+".json")
+}
+
+func readPartInfo(node *nodes.FileNode) (partInfo, bool) {
+	var info partInfo
+	raw, err := os.ReadFile(partInfoPath(node))
 	if err != nil {
-		return err
+		return info, false
 	}
-	defer res.Body.Close()
-	_, err = io.Copy(file, res.Body)
+	if err := json.Unmarshal(raw, &info); err != nil {
+		return info, false
+	}
+	return info, true
+}
+
+func writePartInfo(node *nodes.FileNode, header http.Header) error {
+	validator := header.Get("ETag")
+	if validator == "" || strings.HasPrefix(validator, "W/") {
+		// weak etags can't be used for range requests
+		validator = header.Get("Last-Modified")
+	}
+	raw, err := json.Marshal(partInfo{UpdatedAt: node.UpdatedAt, Validator: validator})
 	if err != nil {
 		return err
 	}
-	return nil
+	return os.WriteFile(partInfoPath(node), raw, 0644)
 }
 
-func (c *CanvasClient) RecursiveCreateNode(node *nodes.DirectoryNode, updateNumDownloads func(numDownloads int)) error {
-	if node == nil {
-		return errors.New("cannot recurse nil directory node")
+// removePart deletes a partial download and its info
+func removePart(node *nodes.FileNode) {
+	os.Remove(partPath(node))
+	os.Remove(partInfoPath(node))
+}
+
+// downloadFileNodeOnce downloads into a .part file, resuming a previous partial download of the same remote file if
+// the server supports range requests, and renames it into place once complete. Returns the sha256 and size of the
+// contents.
+func (c *CanvasClient) downloadFileNodeOnce(ctx context.Context, node *nodes.FileNode) (string, int64, error) {
+	part := partPath(node)
+	var offset int64
+	if stat, err := os.Stat(part); err == nil {
+		offset = stat.Size()
+	}
+	info, ok := readPartInfo(node)
+	if offset > 0 && (!ok || !info.UpdatedAt.Equal(node.UpdatedAt)) {
+		// the file was replaced on canvas since the part was downloaded (or it can't be told), start over
+		removePart(node)
+		offset = 0
+	}
+	req, err := c.newRequest(ctx, "GET", node.Url)
+	if err != nil {
+		return "", 0, err
 	}
-	if err := os.MkdirAll(node.Directory, 0755); err != nil {
-		return err
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+		if info.Validator != "" {
+			req.Header.Set("If-Range", info.Validator)
+		}
 	}
-	var wg sync.WaitGroup
-	numDownloads := 0
-	for j := range node.FileNodes {
-		if node.FileNodes[j] == nil {
-			continue
+	res, err := c.client.Do(req)
+	if err != nil {
+		return "", 0, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode == http.StatusRequestedRangeNotSatisfiable && offset > 0 {
+		// the partial file doesn't match the remote file anymore, start over
+		res.Body.Close()
+		removePart(node)
+		return c.downloadFileNodeOnce(ctx, node)
+	}
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		body, _ := io.ReadAll(res.Body)
+		return "", 0, newAPIError(res, body)
+	}
+
+	hash := sha256.New()
+	flags := os.O_CREATE | os.O_WRONLY | os.O_APPEND
+	if res.StatusCode == http.StatusPartialContent {
+		if contentRangeStart(res.Header) != offset {
+			// never take part of a file for the whole of it, or append it at the wrong place
+			res.Body.Close()
+			removePart(node)
+			if offset > 0 {
+				return c.downloadFileNodeOnce(ctx, node)
+			}
+			return "", 0, fmt.Errorf("expected the whole file but got %s", res.Header.Get("Content-Range"))
 		}
-		wg.Add(1)
-		numDownloads += 1
-		go func(i int) {
-			defer wg.Done()
-			var err error
-			err = c.downloadFileNode(node.FileNodes[i])
-			for err != nil {
-				pterm.Error.Printfln("Error downloading file %s: %s", node.FileNodes[i].Display_name, err.Error())
-				err = c.downloadFileNode(node.FileNodes[i])
+		if offset > 0 {
+			if err := hashFileInto(part, hash); err != nil {
+				return "", 0, err
 			}
-		}(j)
+		}
+	} else {
+		// range not supported or the remote file changed (If-Range), the whole file is being sent again
+		offset = 0
+		flags = os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+	}
+	if offset == 0 {
+		if err := writePartInfo(node, res.Header); err != nil {
+			return "", 0, err
+		}
+	}
+	file, err := os.OpenFile(part, flags, 0644)
+	if err != nil {
+		return "", 0, err
 	}
-	updateNumDownloads(numDownloads)
-	for d := range node.FolderNodes {
+	written, err := io.Copy(io.MultiWriter(file, hash), res.Body)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return "", 0, err
+	}
+	size := offset + written
+	if node.Size > 0 && size != node.Size {
+		if size < node.Size {
+			// truncated, retrying resumes from here
+			return "", 0, io.ErrUnexpectedEOF
+		}
+		removePart(node)
+		return "", 0, fmt.Errorf("downloaded %d bytes but canvas reported %d", size, node.Size)
+	}
+	if err := os.Rename(part, node.Directory); err != nil {
+		return "", 0, err
+	}
+	os.Remove(partInfoPath(node))
+	return hex.EncodeToString(hash.Sum(nil)), size, nil
+}
+
+// contentRangeStart returns the first byte of a partial response, -1 if it can't be parsed
+func contentRangeStart(header http.Header) int64 {
+	var start, end int64
+	if _, err := fmt.Sscanf(header.Get("Content-Range"), "bytes %d-%d", &start, &end); err != nil {
+		return -1
+	}
+	return start
+}
+
+func hashFileInto(path string, w io.Writer) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	_, err = io.Copy(w, file)
+	return err
+}
+
+// downloadFileNodes queues every file on the shared scheduler and waits for them, recording files that still fail
+// after retrying in the report
+func (c *CanvasClient) downloadFileNodes(ctx context.Context, files []*nodes.FileNode, m *manifest.Manifest, report *SyncReport) {
+	if len(files) == 0 {
+		return
+	}
+	var wg sync.WaitGroup
+	jobs := make([]*downloadJob, 0, len(files))
+	for _, file := range files {
 		wg.Add(1)
-		go func(i int) {
-			defer wg.Done()
-			if err := c.RecursiveCreateNode(node.FolderNodes[i], updateNumDownloads); err != nil {
-				pterm.Error.Printfln("Error downloading folder %s: %s", node.FileNodes[i].Display_name, err.Error())
-			}
-		}(d)
+		jobs = append(jobs, &downloadJob{ctx: ctx, node: file, m: m, report: report, wg: &wg})
 	}
+	stop := c.scheduler.submit(c, ctx, m.CourseID, jobs)
 	wg.Wait()
-	return nil
+	stop()
+}
+
+// collectCreateNode creates every directory in the tree, returning all files in it that can be downloaded
+func collectCreateNode(node *nodes.DirectoryNode, report *SyncReport) ([]*nodes.FileNode, error) {
+	if err := createSyncableDirs(node); err != nil {
+		return nil, err
+	}
+	return collectSyncableFileNodes(node, report.AddLocked, nil), nil
 }
 
-func (c *CanvasClient) RecursiveUpdateNode(node *nodes.DirectoryNode, updateStaleFiles bool, updateNumDownloads func(numDownloads int)) error {
+func (c *CanvasClient) RecursiveCreateNode(ctx context.Context, node *nodes.DirectoryNode, m *manifest.Manifest, report *SyncReport, updateNumDownloads func(numDownloads int)) error {
 	if node == nil {
 		return errors.New("cannot recurse nil directory node")
 	}
-	// create directory if doesnt exist
-	if _, err := os.Stat(node.Directory); os.IsNotExist(err) {
-		if err := os.MkdirAll(node.Directory, 0755); err != nil {
-			return err
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	toDownload, err := collectCreateNode(node, report)
+	if err != nil {
+		return err
+	}
+	updateNumDownloads(len(toDownload))
+	c.downloadFileNodes(ctx, toDownload, m, report)
+	return nil
+}
+
+// UpdateOptions controls which already downloaded files are downloaded again
+type UpdateOptions struct {
+	// redownload files updated on canvas since they were last downloaded
+	Force bool
+	// rehash local files, redownloading any that no longer match the manifest
+	Verify bool
+}
+
+// needsDownload decides whether a file has to be (re)downloaded, using the manifest rather than local mtimes
+func needsDownload(node *nodes.FileNode, m *manifest.Manifest, opts UpdateOptions) (bool, error) {
+	info, err := os.Stat(node.Directory)
+	if os.IsNotExist(err) {
+		return true, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	entry := m.Get(node.Directory)
+	if entry == nil {
+		// downloaded before the manifest existed, fall back to mtimes once and adopt the local copy
+		if opts.Force && info.ModTime().Before(node.UpdatedAt) {
+			return true, nil
+		}
+		hash, err := manifest.HashFile(node.Directory)
+		if err != nil {
+			return false, err
+		}
+		m.Put(node.Directory, manifest.Entry{
+			FileID:     node.ID,
+			FolderID:   node.FolderID,
+			Size:       info.Size(),
+			UpdatedAt:  node.UpdatedAt,
+			CanvasPath: node.CanvasPath,
+			Hash:       hash,
+		})
+		return false, nil
+	}
+	remoteChanged := entry.FileID != node.ID || node.UpdatedAt.After(entry.UpdatedAt) || (node.Size > 0 && node.Size != entry.Size)
+	if opts.Force && remoteChanged {
+		return true, nil
+	}
+	if opts.Verify {
+		if info.Size() != entry.Size {
+			return true, nil
 		}
+		hash, err := manifest.HashFile(node.Directory)
+		if err != nil {
+			return false, err
+		}
+		return hash != entry.Hash, nil
 	}
-	var wg sync.WaitGroup
-	numDownloads := 0
-	for j := range node.FileNodes {
-		if node.FileNodes[j] == nil {
+	return false, nil
+}
+
+// collectUpdateNode creates missing directories in the tree, returning the files that need to be downloaded
+func collectUpdateNode(node *nodes.DirectoryNode, m *manifest.Manifest, opts UpdateOptions, report *SyncReport) ([]*nodes.FileNode, error) {
+	if err := createSyncableDirs(node); err != nil {
+		return nil, err
+	}
+	toDownload := make([]*nodes.FileNode, 0)
+	// locked files aren't in the manifest until downloaded, so they're picked up by a later update once unlocked
+	for _, file := range collectSyncableFileNodes(node, report.AddLocked, nil) {
+		download, err := needsDownload(file, m, opts)
+		if err != nil {
+			report.AddFailed(file.Directory, err)
 			continue
 		}
-		file, err := os.Stat(node.FileNodes[j].Directory)
-		if err != nil {
-			wg.Add(1)
-			numDownloads += 1
-			go func(i int) {
-				defer wg.Done()
-				var err error
-				err = c.downloadFileNode(node.FileNodes[i])
-				for err != nil {
-					pterm.Error.Printfln("Error downloading file %s: %s", node.FileNodes[i].Display_name, err.Error())
-					err = c.downloadFileNode(node.FileNodes[i])
-				}
-			}(j)
-		} else {
-			if updateStaleFiles && file.ModTime().Unix() < node.FileNodes[j].UpdatedAt.Unix() {
-				wg.Add(1)
-				numDownloads += 1
-				go func(i int) {
-					defer wg.Done()
-					if err := c.downloadFileNode(node.FileNodes[i]); err != nil {
-						pterm.Error.Printfln("Error downloading file %s: %s", node.FileNodes[i].Display_name, err.Error())
-					}
-				}(j)
-			}
+		if download {
+			toDownload = append(toDownload, file)
 		}
 	}
-	updateNumDownloads(numDownloads)
-	for d := range node.FolderNodes {
-		wg.Add(1)
-		go func(i int) {
-			defer wg.Done()
-			if err := c.RecursiveUpdateNode(node.FolderNodes[i], updateStaleFiles, updateNumDownloads); err != nil {
-				pterm.Error.Printfln("Error updating folder %s: %s", node.FileNodes[i].Display_name, err.Error())
-			}
-		}(d)
+	return toDownload, nil
+}
+
+func (c *CanvasClient) RecursiveUpdateNode(ctx context.Context, node *nodes.DirectoryNode, m *manifest.Manifest, opts UpdateOptions, report *SyncReport, updateNumDownloads func(numDownloads int)) error {
+	if node == nil {
+		return errors.New("cannot recurse nil directory node")
 	}
-	wg.Wait()
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	toDownload, err := collectUpdateNode(node, m, opts, report)
+	if err != nil {
+		return err
+	}
+	updateNumDownloads(len(toDownload))
+	c.downloadFileNodes(ctx, toDownload, m, report)
 	return nil
 }
 
 func extractEventFromString(rawJson string) ([]nodes.EventNode, error) {
 	var events []nodes.EventNode
-	json.Unmarshal([]byte(rawJson), &events)
-	if strings.Contains(rawJson, "user authorisation required") {
-		return nil, errors.New("invalid auth cookies/access token, request unauthorized")
+	if err := json.Unmarshal([]byte(rawJson), &events); err != nil {
+		return nil, fmt.Errorf("failed to parse events: %w", err)
 	}
 	return events, nil
 }
 
-func (c *CanvasClient) GetRecentCalendarEvents() ([]nodes.EventNode, error) {
+func (c *CanvasClient) GetRecentCalendarEvents(ctx context.Context) ([]nodes.EventNode, error) {
 	now := utils.TimestampToJavaScriptISO(time.Now())
 	eventsUrl := url.URL{
 		Scheme: c.apiPath.Scheme,
@@ -369,30 +746,12 @@ func (c *CanvasClient) GetRecentCalendarEvents() ([]nodes.EventNode, error) {
 		RawQuery: url.Values{
 			"end_date": {now},
 			"order":    {"asc"},
-			"per_page": {"50"},
 		}.Encode(),
 	}
-
-	// events request
-	req, err := http.NewRequest("GET", eventsUrl.String(), nil)
-	utils.SetQueryAccessToken(req, c.accessToken)
-	if err != nil {
-		return nil, err
-	}
-
-	resp, err := c.client.Do(req)
-	if err != nil {
-		return nil, err
-	}
-	eventsJson := utils.ExtractResponseToString(resp)
-	events, err := extractEventFromString(eventsJson)
-	if err != nil {
-		return nil, err
-	}
-	return events, nil
+	return getPaginated(ctx, c, eventsUrl, extractEventFromString)
 }
 
-func (c *CanvasClient) GetIncomingCalendarEvents() ([]nodes.EventNode, error) {
+func (c *CanvasClient) GetIncomingCalendarEvents(ctx context.Context) ([]nodes.EventNode, error) {
 	now := utils.TimestampToJavaScriptISO(time.Now())
 	eventsUrl := url.URL{
 		Scheme: c.apiPath.Scheme,
@@ -400,52 +759,25 @@ func (c *CanvasClient) GetIncomingCalendarEvents() ([]nodes.EventNode, error) {
 		Path:   c.apiPath.Path + "/planner/items",
 		RawQuery: url.Values{
 			"start_date": {now},
-			"per_page":   {"50"},
 		}.Encode(),
 	}
-
-	// events request
-	req, err := http.NewRequest("GET", eventsUrl.String(), nil)
-	utils.SetQueryAccessToken(req, c.accessToken)
-	if err != nil {
-		return nil, err
-	}
-
-	resp, err := c.client.Do(req)
-	if err != nil {
-		return nil, err
-	}
-	eventsJson := utils.ExtractResponseToString(resp)
-	events, err := extractEventFromString(eventsJson)
-	if err != nil {
-		return nil, err
-	}
-	return events, nil
+	return getPaginated(ctx, c, eventsUrl, extractEventFromString)
 }
 
 func extractPeopleFromString(rawJson string) ([]nodes.PersonNode, error) {
 	var people []nodes.PersonNode
-	json.Unmarshal([]byte(rawJson), &people)
-	if strings.Contains(rawJson, "user authorisation required") {
-		return nil, errors.New("invalid auth cookies/access token, request unauthorized")
+	if err := json.Unmarshal([]byte(rawJson), &people); err != nil {
+		return nil, fmt.Errorf("failed to parse people: %w", err)
 	}
 	return people, nil
 }
 
-func (c *CanvasClient) GetCoursePeople(code string) ([]nodes.PersonNode, error) {
-	rawCourses, err := c.GetActiveEnrolledCourses()
+func (c *CanvasClient) GetCoursePeople(ctx context.Context, code string) ([]nodes.PersonNode, error) {
+	course, err := c.ResolveCourse(ctx, code)
 	if err != nil {
 		return nil, err
 	}
-	var courseId int
-	for _, raw := range rawCourses {
-		if strings.EqualFold(code, raw.CourseCode) {
-			courseId = raw.ID
-		}
-	}
-	if courseId == 0 {
-		return nil, errors.New("course not found")
-	}
+	courseId := course.ID
 
 	peopleUrl := url.URL{
 		Scheme: c.apiPath.Scheme,
@@ -453,80 +785,37 @@ func (c *CanvasClient) GetCoursePeople(code string) ([]nodes.PersonNode, error)
 		Path:   c.apiPath.Path + "/courses/" + strconv.Itoa(courseId) + "/users",
 		RawQuery: url.Values{
 			"include[]": {"avatar_url", "observed_users"},
-			"per_page":  {"100"},
-			"page":      {"1"},
 		}.Encode(),
 	}
-
-	req, err := http.NewRequest("GET", peopleUrl.String(), nil)
-	utils.SetQueryAccessToken(req, c.accessToken)
-	if err != nil {
-		return nil, err
-	}
-
-	resp, err := c.client.Do(req)
-	if err != nil {
-		return nil, err
-	}
-	peopleJson := utils.ExtractResponseToString(resp)
-	people, err := extractPeopleFromString(peopleJson)
-	if err != nil {
-		return nil, err
-	}
-	return people, nil
+	return getPaginated(ctx, c, peopleUrl, extractPeopleFromString)
 }
 
 func extractAnnouncementsFromString(rawJson string) ([]nodes.AnnouncementNode, error) {
 	var announcements []nodes.AnnouncementNode
-	json.Unmarshal([]byte(rawJson), &announcements)
-	if strings.Contains(rawJson, "user authorisation required") {
-		return nil, errors.New("invalid auth cookies/access token, request unauthorized")
+	if err := json.Unmarshal([]byte(rawJson), &announcements); err != nil {
+		return nil, fmt.Errorf("failed to parse announcements: %w", err)
 	}
 	return announcements, nil
 }
 
-func (c *CanvasClient) GetCourseAnnouncements(code string) ([]nodes.AnnouncementNode, error) {
-	rawCourses, err := c.GetActiveEnrolledCourses()
+func (c *CanvasClient) GetCourseAnnouncements(ctx context.Context, code string) ([]nodes.AnnouncementNode, error) {
+	course, err := c.ResolveCourse(ctx, code)
 	if err != nil {
 		return nil, err
 	}
-	var courseId int
-	for _, raw := range rawCourses {
-		if strings.EqualFold(code, raw.CourseCode) {
-			courseId = raw.ID
-		}
-	}
-	if courseId == 0 {
-		return nil, errors.New("course not found")
-	}
+	return c.GetCourseAnnouncementsByID(ctx, course.ID)
+}
 
-	peopleUrl := url.URL{
+func (c *CanvasClient) GetCourseAnnouncementsByID(ctx context.Context, courseId int) ([]nodes.AnnouncementNode, error) {
+	announcementsUrl := url.URL{
 		Scheme: c.apiPath.Scheme,
 		Host:   c.apiPath.Host,
 		Path:   c.apiPath.Path + "/courses/" + strconv.Itoa(courseId) + "/discussion_topics",
 		RawQuery: url.Values{
 			"only_announcements": {"true"},
-			"per_page":           {"50"},
-			"page":               {"1"},
 		}.Encode(),
 	}
-
-	req, err := http.NewRequest("GET", peopleUrl.String(), nil)
-	utils.SetQueryAccessToken(req, c.accessToken)
-	if err != nil {
-		return nil, err
-	}
-
-	resp, err := c.client.Do(req)
-	if err != nil {
-		return nil, err
-	}
-	announcementsJson := utils.ExtractResponseToString(resp)
-	announcements, err := extractAnnouncementsFromString(announcementsJson)
-	if err != nil {
-		return nil, err
-	}
-	return announcements, nil
+	return getPaginated(ctx, c, announcementsUrl, extractAnnouncementsFromString)
 }
 
 type CourseVideoFile struct {
@@ -543,7 +832,7 @@ type CourseVideoFolder struct {
 	Folders []*CourseVideoFolder
 }
 
-func (c *CanvasClient) extractVideoAudioUrlFromFile(page playwright.Page, file *CourseVideoFile, increment func()) {
+func (c *CanvasClient) extractVideoAudioUrlFromFile(ctx context.Context, page playwright.Page, file *CourseVideoFile, increment func()) {
 	sourceVideoUrlMap := make(map[string]map[string]interface{}, 0)
 
 	// extract m3u8 urls
@@ -569,7 +858,11 @@ func (c *CanvasClient) extractVideoAudioUrlFromFile(page playwright.Page, file *
 	if _, err := page.WaitForEvent("request"); err != nil {
 		pterm.Error.Printfln("failed to wait for request for %s", file.SourceUrl)
 	}
-	time.Sleep(2 * time.Second)
+	select {
+	case <-time.After(2 * time.Second):
+	case <-ctx.Done():
+		return
+	}
 
 	// extract media urls from map
 	mediaUrls := []string{}
@@ -583,7 +876,7 @@ func (c *CanvasClient) extractVideoAudioUrlFromFile(page playwright.Page, file *
 	}
 
 	// set audio/video urls
-	data, err := ffprobe.ProbeURL(context.Background(), mediaUrls[0])
+	data, err := ffprobe.ProbeURL(ctx, mediaUrls[0])
 	if err != nil {
 		pterm.Error.Printfln("failed to ffprobe url %s: %s", mediaUrls[0], err.Error())
 		return
@@ -603,7 +896,7 @@ func (c *CanvasClient) extractVideoAudioUrlFromFile(page playwright.Page, file *
 		return
 	}
 
-	data, err = ffprobe.ProbeURL(context.Background(), mediaUrls[1])
+	data, err = ffprobe.ProbeURL(ctx, mediaUrls[1])
 	if err == nil {
 		// second url contains audio
 		if data.FirstAudioStream() != nil {
@@ -616,22 +909,101 @@ func (c *CanvasClient) extractVideoAudioUrlFromFile(page playwright.Page, file *
 	}
 }
 
-func (c *CanvasClient) extractVideoAudioUrlFromFolder(page playwright.Page, folder *CourseVideoFolder, increment func(isFile bool)) {
+func (c *CanvasClient) extractVideoAudioUrlFromFolder(ctx context.Context, page playwright.Page, folder *CourseVideoFolder, increment func(isFile bool)) {
 	for _, fold := range folder.Folders {
-		c.extractVideoAudioUrlFromFolder(page, fold, increment)
+		if ctx.Err() != nil {
+			return
+		}
+		c.extractVideoAudioUrlFromFolder(ctx, page, fold, increment)
 	}
 	incrementFile := func() {
 		increment(true)
 	}
 	for _, fil := range folder.Videos {
-		c.extractVideoAudioUrlFromFile(page, fil, incrementFile)
+		if ctx.Err() != nil {
+			return
+		}
+		c.extractVideoAudioUrlFromFile(ctx, page, fil, incrementFile)
+	}
+}
+
+// videoID returns the id of a video from its viewer url (e.g. Viewer.aspx?id=<guid>), falling back to a hash of the url
+func videoID(videoUrl string) string {
+	if parsed, err := url.Parse(videoUrl); err == nil {
+		if id := parsed.Query().Get("id"); id != "" {
+			return id
+		}
+	}
+	return fmt.Sprintf("%x", sha256.Sum256([]byte(videoUrl)))
+}
+
+// videoItem is a video or folder listed in a video folder, ids are only unique among items of the same kind
+type videoItem struct {
+	name string
+	id   string
+}
+
+// claimVideoNames picks unique sanitised names for the items listed in a video folder, in the same way as
+// setTreeDirectories: names that collide case-insensitively get (the start of) the item's id appended, the item with
+// the lowest id keeping the name, so names don't depend on the order videos are listed in
+func claimVideoNames(items []videoItem, taken map[string]bool, withSuffix func(name string, suffix string) string) []string {
+	order := make([]int, len(items))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return items[order[i]].id < items[order[j]].id
+	})
+	names := make([]string, len(items))
+	for _, i := range order {
+		name := utils.SanitiseFilename(items[i].name)
+		if taken[strings.ToLower(name)] {
+			id := items[i].id
+			if len(id) > 8 {
+				id = id[:8]
+			}
+			name = withSuffix(name, fmt.Sprintf(" (%s)", id))
+			// items listed with the exact same name and id
+			for n := 2; taken[strings.ToLower(name)]; n++ {
+				name = withSuffix(utils.SanitiseFilename(items[i].name), fmt.Sprintf(" (%s-%d)", id, n))
+			}
+		}
+		taken[strings.ToLower(name)] = true
+		names[i] = name
 	}
+	return names
 }
 
-func (c *CanvasClient) extractCurrentVideoFolder(page playwright.Page, folderPath string, increment func(isFile bool)) *CourseVideoFolder {
+// adoptLegacyVideo moves a video downloaded before names were sanitised to its new path, so it isn't downloaded again
+func adoptLegacyVideo(legacyPath string, videoPath string, videosDir string) {
+	if legacyPath == videoPath {
+		return
+	}
+	if _, err := os.Stat(videoPath); err == nil {
+		return
+	}
+	if info, err := os.Stat(legacyPath); err != nil || info.IsDir() {
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(videoPath), 0755); err != nil {
+		pterm.Error.Printfln("failed to move %s to %s: %s", legacyPath, videoPath, err)
+		return
+	}
+	if err := os.Rename(legacyPath, videoPath); err != nil {
+		pterm.Error.Printfln("failed to move %s to %s: %s", legacyPath, videoPath, err)
+		return
+	}
+	utils.RemoveEmptyParents(filepath.Dir(legacyPath), videosDir)
+}
+
+// extractCurrentVideoFolder lists the videos and folders in the open video folder, downloaded into folderPath.
+// legacyPath is where the folder was downloaded before names were sanitised.
+func (c *CanvasClient) extractCurrentVideoFolder(ctx context.Context, page playwright.Page, videosDir string, folderPath string, legacyPath string, increment func(isFile bool)) *CourseVideoFolder {
 	frameLoc := page.FrameLocator(".tool_launch")
 	currentVideos := []*CourseVideoFile{}
 	currentFolders := []*CourseVideoFolder{}
+	// videos and folders share a namespace in the folder
+	taken := make(map[string]bool)
 
 	videoTableLocs := frameLoc.Locator("#listViewContainer")
 	videoTableLocs.WaitFor()
@@ -642,36 +1014,40 @@ func (c *CanvasClient) extractCurrentVideoFolder(page playwright.Page, folderPat
 	// if len(videoLocs) == 0 {
 	// 	pterm.Info.Printfln("found 0 videos in %s", folderPath)
 	// }
-	if len(videoLocs) > 0 {
-		for _, videoLoc := range videoLocs {
-			videoUrlLoc := videoLoc.GetByRole("link").First()
-			videoUrl, err := videoUrlLoc.GetAttribute("href")
-			// no valid video url found
-			if err != nil {
-				pterm.Error.Printfln("no valid video url found, skipping")
-				continue
-			}
-			videoName, err := videoUrlLoc.TextContent()
-			// no valid video name found
-			if err != nil {
-				pterm.Error.Printfln("no valid video name found, skipping")
-				continue
-			}
-			videoName = strings.Trim(videoName, " \n")
-			videoName = strings.ReplaceAll(videoName, ",", "")
-			videoName = strings.ReplaceAll(videoName, "/", "-")
-			videoName = fmt.Sprintf("%s.mp4", videoName)
-			videoPath := filepath.Join(folderPath, videoName)
-			fileDownloaded := false
-			if _, err := os.Stat(videoPath); err == nil {
-				fileDownloaded = true
-			}
-			currentVideos = append(currentVideos, &CourseVideoFile{
-				Path:       videoPath,
-				SourceUrl:  videoUrl,
-				Downloaded: fileDownloaded,
-			})
+	videoItems := []videoItem{}
+	videoUrls := []string{}
+	for _, videoLoc := range videoLocs {
+		videoUrlLoc := videoLoc.GetByRole("link").First()
+		videoUrl, err := videoUrlLoc.GetAttribute("href")
+		// no valid video url found
+		if err != nil {
+			pterm.Error.Printfln("no valid video url found, skipping")
+			continue
+		}
+		videoName, err := videoUrlLoc.TextContent()
+		// no valid video name found
+		if err != nil {
+			pterm.Error.Printfln("no valid video name found, skipping")
+			continue
+		}
+		videoName = strings.Trim(videoName, " \n")
+		// commas have always been dropped from video names, kept so existing downloads are still found
+		videoName = strings.ReplaceAll(videoName, ",", "")
+		videoItems = append(videoItems, videoItem{name: videoName + ".mp4", id: videoID(videoUrl)})
+		videoUrls = append(videoUrls, videoUrl)
+	}
+	for i, videoName := range claimVideoNames(videoItems, taken, utils.WithNameSuffix) {
+		videoPath := filepath.Join(folderPath, videoName)
+		adoptLegacyVideo(filepath.Join(legacyPath, strings.ReplaceAll(videoItems[i].name, "/", "-")), videoPath, videosDir)
+		fileDownloaded := false
+		if _, err := os.Stat(videoPath); err == nil {
+			fileDownloaded = true
 		}
+		currentVideos = append(currentVideos, &CourseVideoFile{
+			Path:       videoPath,
+			SourceUrl:  videoUrls[i],
+			Downloaded: fileDownloaded,
+		})
 	}
 
 	expandSubfoldersLoc := frameLoc.Locator(".expand-subfolders")
@@ -684,8 +1060,22 @@ func (c *CanvasClient) extractCurrentVideoFolder(page playwright.Page, folderPat
 	if err != nil {
 		pterm.Error.Printfln("err getting .subfolder-item: %s", err)
 	} else {
+		// folders aren't listed with an id, name them all up front so that folders whose names only collide once
+		// sanitised are told apart by their original name rather than the order they're opened in
+		folderItems := make([]videoItem, len(folderLocs))
+		for i, folderLoc := range folderLocs {
+			folderName, _ := folderLoc.TextContent()
+			folderName = strings.Trim(folderName, " \n")
+			folderItems[i] = videoItem{name: folderName, id: fmt.Sprintf("%x", sha256.Sum256([]byte(folderName)))}
+		}
+		folderNames := claimVideoNames(folderItems, taken, func(name string, suffix string) string {
+			return name + suffix
+		})
 		if len(folderLocs) > 0 {
-			for _, folderLoc := range folderLocs {
+			for i, folderLoc := range folderLocs {
+				if ctx.Err() != nil {
+					break
+				}
 				visible, err := folderLoc.IsVisible()
 				if err != nil {
 					pterm.Error.Printfln("err getting folder visibility")
@@ -706,8 +1096,7 @@ func (c *CanvasClient) extractCurrentVideoFolder(page playwright.Page, folderPat
 					pterm.Error.Printfln("err clicking on folder '%s': %v", folderName, err)
 					continue
 				}
-				folderName = filepath.Join(folderPath, folderName)
-				folder := c.extractCurrentVideoFolder(page, folderName, increment)
+				folder := c.extractCurrentVideoFolder(ctx, page, videosDir, filepath.Join(folderPath, folderNames[i]), filepath.Join(legacyPath, folderName), increment)
 				currentFolders = append(currentFolders, folder)
 
 				// increment folder count
@@ -732,7 +1121,7 @@ func (c *CanvasClient) extractCurrentVideoFolder(page playwright.Page, folderPat
 	}
 }
 
-func (c *CanvasClient) GetCourseVideos(page playwright.Page, dataDir string, course nodes.CourseNode, increment func(isFile bool)) (*CourseVideoFolder, error) {
+func (c *CanvasClient) GetCourseVideos(ctx context.Context, page playwright.Page, dataDir string, course nodes.CourseNode, increment func(isFile bool)) (*CourseVideoFolder, error) {
 	var VIDEO_TIMEOUT float64 = 30_000
 	courseUrl := url.URL{
 		Scheme: c.canvasPath.Scheme,
@@ -752,22 +1141,17 @@ func (c *CanvasClient) GetCourseVideos(page playwright.Page, dataDir string, cou
 		return nil, fmt.Errorf("course %v has no videos", course.CourseCode)
 	}
 
-	courseVideosPath := filepath.Join(dataDir, course.CourseCode, "videos")
-	courseFolder := c.extractCurrentVideoFolder(page, courseVideosPath, increment)
-	for _, fold := range courseFolder.Folders {
-		c.extractVideoAudioUrlFromFolder(page, fold, increment)
-	}
-	incrementFile := func() {
-		increment(true)
-	}
-	for _, vid := range courseFolder.Videos {
-		c.extractVideoAudioUrlFromFile(page, vid, incrementFile)
+	courseVideosPath := filepath.Join(dataDir, course.CourseCode, VIDEOS_DIR)
+	courseFolder := c.extractCurrentVideoFolder(ctx, page, courseVideosPath, courseVideosPath, courseVideosPath, increment)
+	c.extractVideoAudioUrlFromFolder(ctx, page, courseFolder, increment)
+	if err := ctx.Err(); err != nil {
+		return nil, err
 	}
 	return courseFolder, nil
 }
 
-func (c *CanvasClient) GetCourseGrades(code string) error {
-	// rawCourses, err := c.GetActiveEnrolledCourses()
+func (c *CanvasClient) GetCourseGrades(ctx context.Context, code string) error {
+	// rawCourses, err := c.GetEnrolledCourses(ctx)
 	// if err != nil {
 	// 	return err
 	// }
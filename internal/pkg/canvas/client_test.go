@@ -0,0 +1,295 @@
+package canvas
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/aidanaden/canvas-sync/internal/pkg/manifest"
+	"github.com/aidanaden/canvas-sync/internal/pkg/nodes"
+	"github.com/aidanaden/canvas-sync/internal/pkg/utils"
+)
+
+var fileContent = []byte(strings.Repeat("0123456789", 100))
+var fileModTime = time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+
+func newFileServer(t *testing.T, handler http.HandlerFunc) *nodes.FileNode {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	node := &nodes.FileNode{
+		ID:        1,
+		Url:       server.URL + "/file",
+		Size:      int64(len(fileContent)),
+		UpdatedAt: fileModTime,
+		Directory: filepath.Join(t.TempDir(), "notes.pdf"),
+	}
+	return node
+}
+
+func serveFile(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("ETag", `"v1"`)
+	http.ServeContent(w, r, "notes.pdf", fileModTime, bytes.NewReader(fileContent))
+}
+
+func writePart(t *testing.T, node *nodes.FileNode, content []byte, info *partInfo) {
+	t.Helper()
+	if err := os.WriteFile(partPath(node), content, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if info != nil {
+		raw := fmt.Sprintf(`{"updated_at":%q,"validator":%q}`, info.UpdatedAt.Format(time.RFC3339), info.Validator)
+		if err := os.WriteFile(partInfoPath(node), []byte(raw), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func assertDownloaded(t *testing.T, node *nodes.FileNode) {
+	t.Helper()
+	got, err := os.ReadFile(node.Directory)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, fileContent) {
+		t.Fatalf("downloaded %q, want %q", got, fileContent)
+	}
+	for _, path := range []string{partPath(node), partInfoPath(node)} {
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Errorf("%s was left behind", path)
+		}
+	}
+}
+
+func TestDownloadFileNodeOnceResumes(t *testing.T) {
+	var ranges []string
+	node := newFileServer(t, func(w http.ResponseWriter, r *http.Request) {
+		ranges = append(ranges, r.Header.Get("Range")+" "+r.Header.Get("If-Range"))
+		serveFile(w, r)
+	})
+	writePart(t, node, fileContent[:300], &partInfo{UpdatedAt: fileModTime, Validator: `"v1"`})
+
+	c := NewClient("canvas.example.com", "token")
+	if _, _, err := c.downloadFileNodeOnce(context.Background(), node); err != nil {
+		t.Fatal(err)
+	}
+	assertDownloaded(t, node)
+	if len(ranges) != 1 || ranges[0] != `bytes=300- "v1"` {
+		t.Errorf("requested %q, want a range from the part with If-Range", ranges)
+	}
+}
+
+func TestDownloadFileNodeOnceRestartsStalePart(t *testing.T) {
+	tests := []struct {
+		name string
+		info *partInfo
+	}{
+		{"no info", nil},
+		{"replaced on canvas", &partInfo{UpdatedAt: fileModTime.Add(-time.Hour), Validator: `"v1"`}},
+		{"validator changed", &partInfo{UpdatedAt: fileModTime, Validator: `"v0"`}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			node := newFileServer(t, serveFile)
+			writePart(t, node, bytes.Repeat([]byte("x"), 300), tt.info)
+
+			c := NewClient("canvas.example.com", "token")
+			if _, _, err := c.downloadFileNodeOnce(context.Background(), node); err != nil {
+				t.Fatal(err)
+			}
+			assertDownloaded(t, node)
+		})
+	}
+}
+
+func TestDownloadFileNodeOnceUnexpectedRange(t *testing.T) {
+	var requests atomic.Int32
+	node := newFileServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1) == 1 {
+			// a range that doesn't start where the part ends
+			w.Header().Set("Content-Range", fmt.Sprintf("bytes 500-%d/%d", len(fileContent)-1, len(fileContent)))
+			w.WriteHeader(http.StatusPartialContent)
+			w.Write(fileContent[500:])
+			return
+		}
+		if r.Header.Get("Range") != "" {
+			t.Errorf("restarted download requested range %s", r.Header.Get("Range"))
+		}
+		serveFile(w, r)
+	})
+	writePart(t, node, fileContent[:300], &partInfo{UpdatedAt: fileModTime, Validator: `"v1"`})
+
+	c := NewClient("canvas.example.com", "token")
+	if _, _, err := c.downloadFileNodeOnce(context.Background(), node); err != nil {
+		t.Fatal(err)
+	}
+	assertDownloaded(t, node)
+}
+
+func TestDownloadFileNodeOncePartialWithoutRange(t *testing.T) {
+	node := newFileServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Range", fmt.Sprintf("bytes 500-%d/%d", len(fileContent)-1, len(fileContent)))
+		w.WriteHeader(http.StatusPartialContent)
+		w.Write(fileContent[500:])
+	})
+
+	c := NewClient("canvas.example.com", "token")
+	if _, _, err := c.downloadFileNodeOnce(context.Background(), node); err == nil {
+		t.Fatal("expected an error for a partial response to a full request")
+	}
+	if _, err := os.Stat(node.Directory); !os.IsNotExist(err) {
+		t.Error("a partial response was saved as the whole file")
+	}
+	if _, err := os.Stat(partPath(node)); !os.IsNotExist(err) {
+		t.Error("a partial response was kept to resume from")
+	}
+}
+
+func TestUniqueCourseCodes(t *testing.T) {
+	course := func(id int, code string) nodes.CourseNode {
+		return nodes.CourseNode{ID: id, CourseCode: code}
+	}
+	tests := []struct {
+		name string
+		// course id of the manifest in each existing directory, 0 for a directory without one
+		dirs    map[string]int
+		courses []nodes.CourseNode
+		want    []string
+	}{
+		{"distinct codes", nil, []nodes.CourseNode{course(1, "CS1010"), course(2, "CS2030")}, []string{"CS1010", "CS2030"}},
+		{"first course gets the plain code", nil, []nodes.CourseNode{course(2, "CS1010"), course(1, "CS1010")}, []string{"CS1010", "CS1010-1"}},
+		{"case-insensitive", nil, []nodes.CourseNode{course(1, "cs1010"), course(2, "CS1010")}, []string{"cs1010", "CS1010-2"}},
+		{"directory owner keeps the plain code", map[string]int{"CS1010": 1}, []nodes.CourseNode{course(2, "CS1010"), course(1, "CS1010")}, []string{"CS1010-2", "CS1010"}},
+		{"other course's directory", map[string]int{"CS1010": 1}, []nodes.CourseNode{course(2, "CS1010")}, []string{"CS1010-2"}},
+		{"unclaimed directory", map[string]int{"CS1010": 0}, []nodes.CourseNode{course(2, "CS1010")}, []string{"CS1010"}},
+		{"suffixed directory is kept", map[string]int{"CS1010-2": 2}, []nodes.CourseNode{course(2, "CS1010")}, []string{"CS1010-2"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dataDir := t.TempDir()
+			for dir, courseId := range tt.dirs {
+				m, err := manifest.Load(filepath.Join(dataDir, dir), courseId)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if courseId != 0 {
+					if err := m.Save(); err != nil {
+						t.Fatal(err)
+					}
+				} else if err := os.MkdirAll(filepath.Join(dataDir, dir), 0755); err != nil {
+					t.Fatal(err)
+				}
+			}
+			uniqueCourseCodes(dataDir, tt.courses)
+			for i, course := range tt.courses {
+				if course.CourseCode != tt.want[i] {
+					t.Errorf("course %d got code %s, want %s", course.ID, course.CourseCode, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+// newAPIClient returns a client whose canvas api is served by handler
+func newAPIClient(t *testing.T, handler http.Handler) *CanvasClient {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	c := NewClient("canvas.example.com", "token", WithRetryPolicy(RetryPolicy{MaxAttempts: 1}))
+	t.Cleanup(c.Close)
+	serverUrl, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.canvasPath = serverUrl
+	c.apiPath = serverUrl.JoinPath(apiPath)
+	return c
+}
+
+func TestVideoID(t *testing.T) {
+	tests := []struct {
+		name string
+		url  string
+		want string
+	}{
+		{"viewer url", "https://nus.cloud.panopto.eu/Panopto/Pages/Viewer.aspx?id=3f2a9c1e-0b7d-4a51-9c3e-b0e1a7f4d2c8", "3f2a9c1e-0b7d-4a51-9c3e-b0e1a7f4d2c8"},
+		{"other url", "https://example.com/video", fmt.Sprintf("%x", sha256.Sum256([]byte("https://example.com/video")))},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := videoID(tt.url); got != tt.want {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClaimVideoNames(t *testing.T) {
+	items := []videoItem{
+		{name: "Lecture 1.mp4", id: "bbbbbbbb-2"},
+		{name: "lecture 1.mp4", id: "aaaaaaaa-1"},
+		{name: "Lecture: 2.mp4", id: "cccccccc-3"},
+		{name: "Lecture? 2.mp4", id: "dddddddd-4"},
+	}
+	want := []string{"Lecture 1 (bbbbbbbb).mp4", "lecture 1.mp4", "Lecture_ 2.mp4", "Lecture_ 2 (dddddddd).mp4"}
+	got := claimVideoNames(items, make(map[string]bool), utils.WithNameSuffix)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	// names only depend on ids, not on listing order
+	reversed := []videoItem{items[3], items[2], items[1], items[0]}
+	got = claimVideoNames(reversed, make(map[string]bool), utils.WithNameSuffix)
+	if wantReversed := []string{want[3], want[2], want[1], want[0]}; !reflect.DeepEqual(got, wantReversed) {
+		t.Errorf("got %q after reordering, want %q", got, wantReversed)
+	}
+
+	// the same video listed twice
+	got = claimVideoNames([]videoItem{items[0], items[0]}, make(map[string]bool), utils.WithNameSuffix)
+	if got[0] == got[1] {
+		t.Errorf("both copies are named %s", got[0])
+	}
+}
+
+func TestAdoptLegacyVideo(t *testing.T) {
+	videosDir := t.TempDir()
+	legacyPath := filepath.Join(videosDir, "Week 1: Intro", "Lecture? 1.mp4")
+	videoPath := filepath.Join(videosDir, "Week 1_ Intro", "Lecture_ 1.mp4")
+	if err := os.MkdirAll(filepath.Dir(legacyPath), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(legacyPath, []byte("video"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	adoptLegacyVideo(legacyPath, videoPath, videosDir)
+	if got, err := os.ReadFile(videoPath); err != nil || string(got) != "video" {
+		t.Errorf("video wasn't moved to its sanitised path: %v", err)
+	}
+	if _, err := os.Stat(filepath.Dir(legacyPath)); !os.IsNotExist(err) {
+		t.Error("the empty legacy folder was left behind")
+	}
+
+	// an existing video at the new path is kept
+	if err := os.MkdirAll(filepath.Dir(legacyPath), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(legacyPath, []byte("old"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	adoptLegacyVideo(legacyPath, videoPath, videosDir)
+	if got, _ := os.ReadFile(videoPath); string(got) != "video" {
+		t.Errorf("overwrote the video at the new path with %q", got)
+	}
+}
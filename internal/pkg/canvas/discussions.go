@@ -0,0 +1,245 @@
+package canvas
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aidanaden/canvas-sync/internal/pkg/manifest"
+	"github.com/aidanaden/canvas-sync/internal/pkg/nodes"
+	"github.com/aidanaden/canvas-sync/internal/pkg/utils"
+)
+
+const (
+	DISCUSSIONS_DIR = "discussions"
+	// name of the thread document in each topic's folder
+	DISCUSSION_DOCUMENT        = "discussion"
+	DISCUSSION_ATTACHMENTS_DIR = "attachments"
+)
+
+func extractDiscussionTopicsFromString(rawJson string) ([]nodes.DiscussionTopicNode, error) {
+	var topics []nodes.DiscussionTopicNode
+	if err := json.Unmarshal([]byte(rawJson), &topics); err != nil {
+		return nil, fmt.Errorf("failed to parse discussion topics: %w", err)
+	}
+	return topics, nil
+}
+
+// GetCourseDiscussionTopics returns a course's discussion topics, excluding announcements
+func (c *CanvasClient) GetCourseDiscussionTopics(ctx context.Context, courseId int) ([]nodes.DiscussionTopicNode, error) {
+	return getPaginated(ctx, c, c.getCourseListUrl(courseId, "discussion_topics"), extractDiscussionTopicsFromString)
+}
+
+// GetDiscussionView returns a discussion topic's participants and full threaded replies, including the replies posted
+// since canvas cached the thread
+func (c *CanvasClient) GetDiscussionView(ctx context.Context, courseId int, topicId int) (*nodes.DiscussionViewNode, error) {
+	viewUrl := c.getCourseListUrl(courseId, fmt.Sprintf("discussion_topics/%d/view", topicId))
+	viewJson, _, err := c.get(ctx, viewUrl.String())
+	if err != nil {
+		return nil, err
+	}
+	var view *nodes.DiscussionViewNode
+	if err := json.Unmarshal(viewJson, &view); err != nil {
+		return nil, fmt.Errorf("failed to parse discussion: %w", err)
+	}
+	if view != nil {
+		view.View = threadEntries(view.View, view.NewEntries)
+		view.NewEntries = nil
+	}
+	return view, nil
+}
+
+// threadEntries attaches each new entry under the entry it replies to, or at the top level when it has no parent (or
+// its parent isn't in the thread)
+func threadEntries(view []nodes.DiscussionEntryNode, newEntries []nodes.DiscussionEntryNode) []nodes.DiscussionEntryNode {
+	// replies to new entries come after them
+	sort.SliceStable(newEntries, func(i, j int) bool {
+		return newEntries[i].ID < newEntries[j].ID
+	})
+	for _, entry := range newEntries {
+		if entry.ParentID == nil || !attachReply(view, *entry.ParentID, entry) {
+			view = append(view, entry)
+		}
+	}
+	return view
+}
+
+func attachReply(entries []nodes.DiscussionEntryNode, parentId int, reply nodes.DiscussionEntryNode) bool {
+	for i := range entries {
+		if entries[i].ID == parentId {
+			entries[i].Replies = append(entries[i].Replies, reply)
+			return true
+		}
+		if attachReply(entries[i].Replies, parentId, reply) {
+			return true
+		}
+	}
+	return false
+}
+
+// DiscussionsSource exports the course's discussion topics with their replies, one folder per topic
+var DiscussionsSource = DocumentSource{
+	Dir: DISCUSSIONS_DIR,
+	Sync: func(ctx context.Context, c *CanvasClient, course nodes.CourseNode, courseDir string, m *manifest.Manifest, opts DocumentOptions, report *SyncReport) (int, error) {
+		return c.SyncCourseDiscussions(ctx, course.ID, courseDir, m, report)
+	},
+}
+
+// lastActivity is when a topic last changed, as far as canvas tells
+func lastActivity(topic nodes.DiscussionTopicNode) time.Time {
+	if topic.LastReplyAt != nil {
+		return *topic.LastReplyAt
+	}
+	if topic.PostedAt != nil {
+		return *topic.PostedAt
+	}
+	return time.Time{}
+}
+
+// SyncCourseDiscussions writes every discussion topic of a course with its threaded replies into
+// <courseDir>/discussions/<title>/discussion.html and discussion.md, with the attachments of the topic and its replies
+// in attachments/. Topics without new replies since they were last written are skipped.
+// Returns the number of topics written.
+func (c *CanvasClient) SyncCourseDiscussions(ctx context.Context, courseId int, courseDir string, m *manifest.Manifest, report *SyncReport) (int, error) {
+	topics, err := c.GetCourseDiscussionTopics(ctx, courseId)
+	if err != nil {
+		return 0, err
+	}
+	dir := filepath.Join(courseDir, DISCUSSIONS_DIR)
+	links := c.newCourseLinks(m, courseDir)
+	synced := syncedDocuments(m, dir, DISCUSSION_DOCUMENT)
+	claim := claimNames()
+	sort.SliceStable(topics, func(i, j int) bool {
+		return topics[i].ID < topics[j].ID
+	})
+	written := 0
+	for _, topic := range topics {
+		if ctx.Err() != nil {
+			return written, ctx.Err()
+		}
+		folder := filepath.Join(dir, claim(topic.Title, topic.ID))
+		base := filepath.Join(folder, DISCUSSION_DOCUMENT)
+		htmlPath := base + ".html"
+		if topic.LockedForUser && topic.Message == "" {
+			var unlockAt *time.Time
+			if topic.LockInfo != nil {
+				unlockAt = topic.LockInfo.UnlockAt
+			}
+			report.AddLocked(folder, unlockAt)
+			continue
+		}
+		updatedAt := lastActivity(topic)
+		if entry, ok := synced[topic.ID]; ok {
+			if oldFolder := filepath.Dir(m.LocalPath(&entry)); oldFolder != folder {
+				moveDocumentFolder(m, oldFolder, folder, report)
+			} else if !updatedAt.IsZero() && entry.UpdatedAt.Equal(updatedAt) && documentExists(htmlPath) {
+				continue
+			}
+		}
+
+		view, err := c.GetDiscussionView(ctx, courseId, topic.ID)
+		if err != nil && !errors.Is(err, ErrForbidden) {
+			report.AddFailed(htmlPath, err)
+			continue
+		}
+
+		files := append(c.linkedFiles(ctx, topic.Message, links, report), topic.Attachments...)
+		names := make(map[int]string)
+		if view != nil {
+			for _, participant := range view.Participants {
+				names[participant.ID] = participant.DisplayName
+			}
+			files = append(files, entryAttachments(view.View, nil)...)
+		}
+		local := c.downloadAttachments(ctx, files, filepath.Join(folder, DISCUSSION_ATTACHMENTS_DIR), m, report)
+
+		body := discussionBody(topic, view, names, folder, local)
+		hash, size, err := writeDocument(base, topic.Title, body, links.with(local))
+		if err != nil {
+			report.AddFailed(htmlPath, err)
+			continue
+		}
+		m.Put(htmlPath, manifest.Entry{
+			FileID:     topic.ID,
+			Size:       size,
+			UpdatedAt:  updatedAt,
+			CanvasPath: fmt.Sprintf("discussion_topics/%d", topic.ID),
+			Hash:       hash,
+		})
+		report.AddDownloaded(htmlPath)
+		written++
+	}
+	return written, nil
+}
+
+func attachmentsOf(entry nodes.DiscussionEntryNode) []*nodes.FileNode {
+	if entry.Attachment != nil {
+		return append([]*nodes.FileNode{entry.Attachment}, entry.Attachments...)
+	}
+	return entry.Attachments
+}
+
+func entryAttachments(entries []nodes.DiscussionEntryNode, files []*nodes.FileNode) []*nodes.FileNode {
+	for _, entry := range entries {
+		files = append(files, attachmentsOf(entry)...)
+		files = entryAttachments(entry.Replies, files)
+	}
+	return files
+}
+
+// discussionBody renders a topic's message followed by its replies, each nested as a quote inside the one it replies to
+func discussionBody(topic nodes.DiscussionTopicNode, view *nodes.DiscussionViewNode, names map[int]string, folder string, local map[int]string) string {
+	var b strings.Builder
+	b.WriteString("<ul>")
+	if topic.UserName != "" {
+		fmt.Fprintf(&b, "<li><strong>Author:</strong> %s</li>", html.EscapeString(topic.UserName))
+	}
+	fmt.Fprintf(&b, "<li><strong>Posted:</strong> %s</li>", html.EscapeString(formatDate(topic.PostedAt)))
+	fmt.Fprintf(&b, "<li><strong>Last reply:</strong> %s</li>", html.EscapeString(formatDate(topic.LastReplyAt)))
+	fmt.Fprintf(&b, "<li><strong>Canvas:</strong> <a href=\"%s\">%s</a></li>", html.EscapeString(topic.HtmlUrl), html.EscapeString(topic.HtmlUrl))
+	b.WriteString("</ul>")
+	b.WriteString(topic.Message)
+	b.WriteString(fileList(topic.Attachments, folder, local))
+
+	if view == nil {
+		b.WriteString("<h2>Replies</h2><p>Replies are only visible on canvas after posting to this discussion</p>")
+		return b.String()
+	}
+	fmt.Fprintf(&b, "<h2>Replies (%d)</h2>", countEntries(view.View))
+	for _, entry := range view.View {
+		writeEntry(&b, entry, names, folder, local)
+	}
+	return b.String()
+}
+
+func countEntries(entries []nodes.DiscussionEntryNode) int {
+	count := len(entries)
+	for _, entry := range entries {
+		count += countEntries(entry.Replies)
+	}
+	return count
+}
+
+func writeEntry(b *strings.Builder, entry nodes.DiscussionEntryNode, names map[int]string, folder string, local map[int]string) {
+	name := names[entry.UserID]
+	if name == "" {
+		name = "Unknown"
+	}
+	fmt.Fprintf(b, "<blockquote><p><strong>%s</strong>, %s</p>", html.EscapeString(name), html.EscapeString(utils.FormatEventDate(entry.CreatedAt.Local())))
+	if entry.Deleted {
+		b.WriteString("<p><em>deleted</em></p>")
+	} else {
+		b.WriteString(entry.Message)
+	}
+	b.WriteString(fileList(attachmentsOf(entry), folder, local))
+	for _, reply := range entry.Replies {
+		writeEntry(b, reply, names, folder, local)
+	}
+	b.WriteString("</blockquote>")
+}
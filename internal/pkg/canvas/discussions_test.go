@@ -0,0 +1,137 @@
+package canvas
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/aidanaden/canvas-sync/internal/pkg/manifest"
+	"github.com/aidanaden/canvas-sync/internal/pkg/nodes"
+)
+
+const discussionViewJson = `{
+	"participants": [{"id": 1, "display_name": "Alice"}, {"id": 2, "display_name": "Bob"}, {"id": 3, "display_name": "Carol"}],
+	"view": [{"id": 10, "user_id": 1, "created_at": "2024-03-01T10:00:00Z", "message": "<p>first post</p>",
+		"replies": [{"id": 11, "user_id": 2, "parent_id": 10, "created_at": "2024-03-01T11:00:00Z", "message": "<p>cached reply</p>"}]}],
+	"new_entries": [
+		{"id": 14, "user_id": 1, "parent_id": 13, "created_at": "2024-03-02T12:00:00Z", "message": "<p>reply to the new reply</p>"},
+		{"id": 13, "user_id": 3, "parent_id": 11, "created_at": "2024-03-02T11:00:00Z", "message": "<p>new reply</p>"},
+		{"id": 12, "user_id": 2, "parent_id": null, "created_at": "2024-03-02T10:00:00Z", "message": "<p>new post</p>"}
+	]
+}`
+
+func discussionsClient(t *testing.T, lastReplyAt *string, views *atomic.Int32) *CanvasClient {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v1/courses/1/discussion_topics", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `[{"id": 5, "title": "Week 1", "message": "<p>Introduce yourself</p>", "posted_at": "2024-03-01T09:00:00Z", "last_reply_at": %q}]`, *lastReplyAt)
+	})
+	mux.HandleFunc("/api/v1/courses/1/discussion_topics/5/view", func(w http.ResponseWriter, r *http.Request) {
+		views.Add(1)
+		fmt.Fprint(w, discussionViewJson)
+	})
+	return newAPIClient(t, mux)
+}
+
+func TestThreadEntries(t *testing.T) {
+	parent := func(id int) *int { return &id }
+	view := []nodes.DiscussionEntryNode{{ID: 1, Replies: []nodes.DiscussionEntryNode{{ID: 2, ParentID: parent(1)}}}}
+	newEntries := []nodes.DiscussionEntryNode{
+		{ID: 5, ParentID: parent(4)},
+		{ID: 4, ParentID: parent(2)},
+		{ID: 3},
+		{ID: 6, ParentID: parent(99)},
+	}
+
+	got := threadEntries(view, newEntries)
+	if len(got) != 3 || got[0].ID != 1 || got[1].ID != 3 || got[2].ID != 6 {
+		t.Fatalf("top level entries %v, want 1, 3 and the orphaned 6", got)
+	}
+	replies := got[0].Replies[0].Replies
+	if len(replies) != 1 || replies[0].ID != 4 {
+		t.Fatalf("replies to 2 are %v, want 4", replies)
+	}
+	if len(replies[0].Replies) != 1 || replies[0].Replies[0].ID != 5 {
+		t.Errorf("replies to 4 are %v, want 5", replies[0].Replies)
+	}
+}
+
+func TestSyncCourseDiscussionsRendersNewEntries(t *testing.T) {
+	lastReplyAt := "2024-03-02T12:00:00Z"
+	var views atomic.Int32
+	c := discussionsClient(t, &lastReplyAt, &views)
+	courseDir := t.TempDir()
+	m, err := manifest.Load(courseDir, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := c.SyncCourseDiscussions(context.Background(), 1, courseDir, m, &SyncReport{}); err != nil {
+		t.Fatal(err)
+	}
+	markdown, err := os.ReadFile(filepath.Join(courseDir, DISCUSSIONS_DIR, "Week 1", DISCUSSION_DOCUMENT+".md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := string(markdown)
+	if !strings.Contains(got, "Replies (5)") {
+		t.Errorf("discussion doesn't count the new entries:\n%s", got)
+	}
+	// each reply is quoted one level deeper than the entry it replies to
+	for _, want := range []string{
+		"> first post",
+		"> > cached reply",
+		"> > > new reply",
+		"> > > > reply to the new reply",
+		"> new post",
+	} {
+		if !strings.Contains(got, "\n"+want) {
+			t.Errorf("discussion is missing %q:\n%s", want, got)
+		}
+	}
+	if strings.Index(got, "reply to the new reply") > strings.Index(got, "new post") {
+		t.Errorf("the new top level post isn't after the cached thread:\n%s", got)
+	}
+}
+
+func TestSyncCourseDiscussionsSkipsTopicsWithoutNewReplies(t *testing.T) {
+	lastReplyAt := "2024-03-02T12:00:00Z"
+	var views atomic.Int32
+	c := discussionsClient(t, &lastReplyAt, &views)
+	courseDir := t.TempDir()
+	m, err := manifest.Load(courseDir, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sync := func() int {
+		t.Helper()
+		written, err := c.SyncCourseDiscussions(context.Background(), 1, courseDir, m, &SyncReport{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return written
+	}
+
+	if written := sync(); written != 1 {
+		t.Fatalf("wrote %d topics, want 1", written)
+	}
+	htmlPath := filepath.Join(courseDir, DISCUSSIONS_DIR, "Week 1", DISCUSSION_DOCUMENT+".html")
+	want, _ := time.Parse(time.RFC3339, lastReplyAt)
+	if entry := m.Get(htmlPath); entry == nil || !entry.UpdatedAt.Equal(want) {
+		t.Fatalf("manifest entry for %s is %v, want it updated at the last reply", htmlPath, entry)
+	}
+
+	if written := sync(); written != 0 || views.Load() != 1 {
+		t.Errorf("wrote %d topics and fetched %d views without new replies, want 0 and 1", written, views.Load())
+	}
+
+	lastReplyAt = "2024-03-03T08:00:00Z"
+	if written := sync(); written != 1 || views.Load() != 2 {
+		t.Errorf("wrote %d topics and fetched %d views after a new reply, want 1 and 2", written, views.Load())
+	}
+}
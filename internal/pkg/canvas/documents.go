@@ -0,0 +1,257 @@
+package canvas
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/aidanaden/canvas-sync/internal/pkg/manifest"
+	"github.com/aidanaden/canvas-sync/internal/pkg/nodes"
+	"github.com/aidanaden/canvas-sync/internal/pkg/richtext"
+	"github.com/aidanaden/canvas-sync/internal/pkg/utils"
+)
+
+// DocumentOptions controls how course content (pages, assignments, ...) is synced
+type DocumentOptions struct {
+	// only rewrite documents that changed on canvas since they were last synced
+	OnlyChanged bool
+	// also write announcements into an mbox mailbox
+	Mbox bool
+}
+
+// DocumentSource is course content synced as html and markdown documents into Dir under the course's directory
+type DocumentSource struct {
+	Dir string
+	// Sync writes the course's documents into courseDir/Dir, returning the number of documents written
+	Sync func(ctx context.Context, c *CanvasClient, course nodes.CourseNode, courseDir string, m *manifest.Manifest, opts DocumentOptions, report *SyncReport) (int, error)
+}
+
+func claimer(withSuffix func(name string, suffix string) string) func(name string, id int) string {
+	taken := make(map[string]bool)
+	return func(name string, id int) string {
+		name = utils.SanitiseFilename(name)
+		if taken[strings.ToLower(name)] {
+			name = withSuffix(name, fmt.Sprintf(" (%d)", id))
+		}
+		taken[strings.ToLower(name)] = true
+		return name
+	}
+}
+
+// claimNames returns a function picking unique sanitised names within a folder, in the same way as
+// setTreeDirectories: names that collide case-insensitively get the item's id appended
+func claimNames() func(name string, id int) string {
+	return claimer(func(name string, suffix string) string {
+		return name + suffix
+	})
+}
+
+// claimFileNames is claimNames for file names, keeping the extension after the id
+func claimFileNames() func(name string, id int) string {
+	return claimer(utils.WithNameSuffix)
+}
+
+func (c *CanvasClient) GetFile(ctx context.Context, fileId int) (*nodes.FileNode, error) {
+	fileUrl := url.URL{
+		Scheme: c.apiPath.Scheme,
+		Host:   c.apiPath.Host,
+		Path:   c.apiPath.Path + fmt.Sprintf("/files/%d", fileId),
+	}
+	fileJson, _, err := c.get(ctx, fileUrl.String())
+	if err != nil {
+		return nil, err
+	}
+	var file *nodes.FileNode
+	if err := json.Unmarshal(fileJson, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse file %d: %w", fileId, err)
+	}
+	return file, nil
+}
+
+// linkedFiles returns the canvas files linked from an html body. Files that can't be accessed (deleted, locked or
+// from another course) are left out and keep linking to canvas.
+func (c *CanvasClient) linkedFiles(ctx context.Context, body string, links *courseLinks, report *SyncReport) []*nodes.FileNode {
+	found, err := richtext.Links(body)
+	if err != nil {
+		return nil
+	}
+	seen := make(map[int]bool)
+	files := make([]*nodes.FileNode, 0)
+	for _, link := range found {
+		id := links.fileID(link)
+		if id == 0 || seen[id] {
+			continue
+		}
+		seen[id] = true
+		file, err := c.GetFile(ctx, id)
+		if errors.Is(err, ErrNotFound) || errors.Is(err, ErrUnauthorized) || errors.Is(err, ErrForbidden) {
+			continue
+		}
+		if err != nil {
+			report.AddFailed(link, err)
+			continue
+		}
+		files = append(files, file)
+	}
+	return files
+}
+
+// downloadAttachments downloads files into dir, skipping those already downloaded and unchanged since.
+// Returns the local paths of the files now in dir by file id.
+func (c *CanvasClient) downloadAttachments(ctx context.Context, files []*nodes.FileNode, dir string, m *manifest.Manifest, report *SyncReport) map[int]string {
+	claim := claimFileNames()
+	local := make(map[int]string)
+	toDownload := make([]*nodes.FileNode, 0)
+	for _, file := range files {
+		if file == nil || file.Url == "" {
+			continue
+		}
+		if _, ok := local[file.ID]; ok {
+			continue
+		}
+		file.Directory = filepath.Join(dir, claim(file.Display_name, file.ID))
+		local[file.ID] = file.Directory
+		download, err := needsDownload(file, m, UpdateOptions{Force: true})
+		if err != nil {
+			report.AddFailed(file.Directory, err)
+			continue
+		}
+		if download {
+			toDownload = append(toDownload, file)
+		}
+	}
+	if len(toDownload) > 0 {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			report.AddFailed(dir, err)
+			return make(map[int]string)
+		}
+	}
+	c.downloadFileNodes(ctx, toDownload, m, report)
+	for id, localPath := range local {
+		if _, err := os.Stat(localPath); err != nil {
+			delete(local, id)
+		}
+	}
+	return local
+}
+
+// writeDocument writes an html body as <base>.html and <base>.md, with links rewritten by links.
+// Returns the sha256 and size of the html file.
+func writeDocument(base string, title string, body string, links *courseLinks) (string, int64, error) {
+	return writeDocumentWithFrontMatter(base, nil, title, body, links)
+}
+
+// writeDocumentWithFrontMatter is writeDocument with yaml front matter (ordered key, value pairs) at the top of the
+// markdown document
+func writeDocumentWithFrontMatter(base string, frontMatter [][2]string, title string, body string, links *courseLinks) (string, int64, error) {
+	document, markdown, err := renderDocument(base, frontMatter, title, body, links)
+	if err != nil {
+		return "", 0, err
+	}
+	return saveDocument(base, document, markdown)
+}
+
+// renderDocument renders the html and markdown documents writeDocumentWithFrontMatter writes to base
+func renderDocument(base string, frontMatter [][2]string, title string, body string, links *courseLinks) ([]byte, string, error) {
+	rewrite := links.rewrite(filepath.Dir(base))
+	rewritten, err := richtext.RewriteLinks(body, rewrite)
+	if err != nil {
+		return nil, "", err
+	}
+	markdown, err := richtext.ToMarkdown(body, rewrite)
+	if err != nil {
+		return nil, "", err
+	}
+	var b strings.Builder
+	if len(frontMatter) > 0 {
+		b.WriteString("---\n")
+		for _, field := range frontMatter {
+			// json strings are valid yaml and escape everything that needs it
+			value, _ := json.Marshal(field[1])
+			fmt.Fprintf(&b, "%s: %s\n", field[0], value)
+		}
+		b.WriteString("---\n\n")
+	}
+	fmt.Fprintf(&b, "# %s\n\n%s", title, markdown)
+	return []byte(richtext.Document(title, rewritten)), b.String(), nil
+}
+
+// saveDocument writes a rendered document as <base>.html and <base>.md, returning the sha256 and size of the html file
+func saveDocument(base string, document []byte, markdown string) (string, int64, error) {
+	if err := os.MkdirAll(filepath.Dir(base), 0755); err != nil {
+		return "", 0, err
+	}
+	if err := os.WriteFile(base+".html", document, 0644); err != nil {
+		return "", 0, err
+	}
+	if err := os.WriteFile(base+".md", []byte(markdown), 0644); err != nil {
+		return "", 0, err
+	}
+	return documentHash(document), int64(len(document)), nil
+}
+
+func documentHash(document []byte) string {
+	hash := sha256.Sum256(document)
+	return hex.EncodeToString(hash[:])
+}
+
+// removeDocument removes both copies of a document written by writeDocument
+func removeDocument(htmlPath string) {
+	base := strings.TrimSuffix(htmlPath, ".html")
+	os.Remove(base + ".html")
+	os.Remove(base + ".md")
+}
+
+func documentExists(htmlPath string) bool {
+	base := strings.TrimSuffix(htmlPath, ".html")
+	for _, path := range []string{base + ".html", base + ".md"} {
+		if _, err := os.Stat(path); err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// moveDocumentFolder renames the folder of a document (renamed on canvas) along with its manifest entries
+func moveDocumentFolder(m *manifest.Manifest, from string, to string, report *SyncReport) {
+	if _, err := os.Stat(to); err == nil {
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(to), 0755); err != nil {
+		report.AddFailed(to, err)
+		return
+	}
+	if err := os.Rename(from, to); err != nil {
+		report.AddFailed(to, err)
+		return
+	}
+	for _, entry := range m.Entries(from) {
+		oldPath := m.LocalPath(&entry)
+		rel, err := filepath.Rel(from, oldPath)
+		if err != nil {
+			continue
+		}
+		m.Move(oldPath, filepath.Join(to, rel))
+	}
+	report.AddMoved(from, to, false)
+}
+
+// syncedDocuments returns the manifest entries of the <name>.html documents under dir by canvas id, leaving out the
+// attachments stored next to them
+func syncedDocuments(m *manifest.Manifest, dir string, name string) map[int]manifest.Entry {
+	documents := make(map[int]manifest.Entry)
+	for _, entry := range m.Entries(dir) {
+		if path.Base(entry.Path) == name+".html" {
+			documents[entry.FileID] = entry
+		}
+	}
+	return documents
+}
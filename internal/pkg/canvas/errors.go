@@ -0,0 +1,175 @@
+package canvas
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/pterm/pterm"
+)
+
+var (
+	ErrUnauthorized = errors.New("canvas request unauthorized")
+	ErrForbidden    = errors.New("canvas request forbidden")
+	ErrNotFound     = errors.New("canvas resource not found")
+	ErrRateLimited  = errors.New("canvas rate limit exceeded")
+	ErrTabDisabled  = errors.New("canvas page disabled for course")
+)
+
+// exit codes returned by commands failing on a canvas api error
+const (
+	EXIT_ERROR        = 1
+	EXIT_UNAUTHORIZED = 2
+	EXIT_FORBIDDEN    = 3
+	EXIT_NOT_FOUND    = 4
+	EXIT_RATE_LIMITED = 5
+	EXIT_TAB_DISABLED = 6
+	// conventional exit code for commands stopped by ctrl-c
+	EXIT_INTERRUPTED = 130
+)
+
+type APIErrorMessage struct {
+	Message string `json:"message"`
+}
+
+// APIError is returned for any non-2xx response from the canvas api
+type APIError struct {
+	StatusCode int
+	Path       string
+	Errors     []APIErrorMessage
+}
+
+func newAPIError(resp *http.Response, body []byte) *APIError {
+	apiErr := &APIError{
+		StatusCode: resp.StatusCode,
+		Path:       resp.Request.URL.Path,
+	}
+	// canvas returns either {"errors": [{"message": ...}]}, {"errors": {"message": ...}} or {"message": ...}
+	var payload struct {
+		Errors  json.RawMessage `json:"errors"`
+		Message string          `json:"message"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		// non-json body e.g. "403 Forbidden (Rate Limit Exceeded)"
+		if message := strings.TrimSpace(string(body)); message != "" {
+			apiErr.Errors = []APIErrorMessage{{Message: message}}
+		}
+		return apiErr
+	}
+	var messages []APIErrorMessage
+	if err := json.Unmarshal(payload.Errors, &messages); err != nil {
+		var single APIErrorMessage
+		if err := json.Unmarshal(payload.Errors, &single); err == nil && single.Message != "" {
+			messages = []APIErrorMessage{single}
+		}
+	}
+	if payload.Message != "" {
+		messages = append(messages, APIErrorMessage{Message: payload.Message})
+	}
+	apiErr.Errors = messages
+	return apiErr
+}
+
+func (e *APIError) Error() string {
+	messages := make([]string, 0, len(e.Errors))
+	for _, m := range e.Errors {
+		messages = append(messages, m.Message)
+	}
+	if len(messages) == 0 {
+		messages = append(messages, http.StatusText(e.StatusCode))
+	}
+	return fmt.Sprintf("canvas returned %d for %s: %s", e.StatusCode, e.Path, strings.Join(messages, ", "))
+}
+
+func (e *APIError) hasMessage(substr string) bool {
+	for _, m := range e.Errors {
+		if strings.Contains(strings.ToLower(m.Message), substr) {
+			return true
+		}
+	}
+	return false
+}
+
+func (e *APIError) isRateLimited() bool {
+	return e.StatusCode == http.StatusTooManyRequests || (e.StatusCode == http.StatusForbidden && e.hasMessage("rate limit exceeded"))
+}
+
+func (e *APIError) isTabDisabled() bool {
+	return e.hasMessage("has been disabled")
+}
+
+// Is allows matching canvas api errors against the Err* sentinels via errors.Is
+func (e *APIError) Is(target error) bool {
+	switch target {
+	case ErrUnauthorized:
+		return e.StatusCode == http.StatusUnauthorized && !e.isTabDisabled()
+	case ErrForbidden:
+		return e.StatusCode == http.StatusForbidden && !e.isRateLimited() && !e.isTabDisabled()
+	case ErrNotFound:
+		return e.StatusCode == http.StatusNotFound && !e.isTabDisabled()
+	case ErrRateLimited:
+		return e.isRateLimited()
+	case ErrTabDisabled:
+		return e.isTabDisabled()
+	}
+	return false
+}
+
+// ErrorHint returns an actionable message for known canvas api errors, empty otherwise
+func ErrorHint(err error) string {
+	switch {
+	case errors.Is(err, ErrUnauthorized):
+		return "Access token is invalid or expired, please run 'canvas-sync init'"
+	case errors.Is(err, ErrRateLimited):
+		return "Canvas is rate limiting requests, please wait a few minutes before trying again"
+	case errors.Is(err, ErrTabDisabled):
+		return "This page has been disabled for the course by its instructors"
+	case errors.Is(err, ErrForbidden):
+		return "You do not have permission to access this on canvas"
+	case errors.Is(err, ErrAmbiguousCourse):
+		return "Use the course id to pick one of the matching courses"
+	case errors.Is(err, ErrNotFound):
+		return "Not found on canvas, please check the course code"
+	}
+	return ""
+}
+
+// ExitCode returns the process exit code matching a canvas api error, or EXIT_INTERRUPTED if the command was cancelled
+func ExitCode(err error) int {
+	switch {
+	case errors.Is(err, context.Canceled):
+		return EXIT_INTERRUPTED
+	case errors.Is(err, ErrUnauthorized):
+		return EXIT_UNAUTHORIZED
+	case errors.Is(err, ErrRateLimited):
+		return EXIT_RATE_LIMITED
+	case errors.Is(err, ErrTabDisabled):
+		return EXIT_TAB_DISABLED
+	case errors.Is(err, ErrForbidden):
+		return EXIT_FORBIDDEN
+	case errors.Is(err, ErrNotFound):
+		return EXIT_NOT_FOUND
+	}
+	return EXIT_ERROR
+}
+
+// ExitOnError prints msg with the error and its hint (if any), then exits with the matching exit code
+func ExitOnError(msg string, err error) {
+	pterm.Error.Printfln("%s: %s", msg, err.Error())
+	if hint := ErrorHint(err); hint != "" {
+		pterm.Info.Println(hint)
+	}
+	os.Exit(ExitCode(err))
+}
+
+// ErrorWithHint formats err followed by its actionable hint (if any) for single-line output e.g. spinners
+func ErrorWithHint(err error) string {
+	if hint := ErrorHint(err); hint != "" {
+		return fmt.Sprintf("%s (%s)", err.Error(), hint)
+	}
+	return err.Error()
+}
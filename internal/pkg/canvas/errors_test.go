@@ -0,0 +1,32 @@
+package canvas
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestExitCode(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{"unauthorized", &APIError{StatusCode: 401}, EXIT_UNAUTHORIZED},
+		{"forbidden", &APIError{StatusCode: 403}, EXIT_FORBIDDEN},
+		{"not found", fmt.Errorf("failed to get course: %w", &APIError{StatusCode: 404}), EXIT_NOT_FOUND},
+		{"rate limited", ErrRateLimited, EXIT_RATE_LIMITED},
+		{"tab disabled", ErrTabDisabled, EXIT_TAB_DISABLED},
+		{"cancelled", context.Canceled, EXIT_INTERRUPTED},
+		{"wrapped cancel", fmt.Errorf("failed to download notes.pdf: %w", context.Canceled), EXIT_INTERRUPTED},
+		{"other", errors.New("disk full"), EXIT_ERROR},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ExitCode(tt.err); got != tt.want {
+				t.Errorf("ExitCode(%v) = %d, want %d", tt.err, got, tt.want)
+			}
+		})
+	}
+}
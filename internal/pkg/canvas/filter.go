@@ -0,0 +1,111 @@
+package canvas
+
+import (
+	"path"
+	"strings"
+
+	"github.com/aidanaden/canvas-sync/internal/pkg/nodes"
+	"github.com/bmatcuk/doublestar/v4"
+)
+
+// FileFilter selects which canvas files are synced. Globs (with ** support) match case-insensitively against
+// paths relative to the course's files e.g. "Lectures/Week 1/slides.pdf".
+type FileFilter struct {
+	// only files matching one of these are synced, all files if empty
+	Include []string
+	// files and folders matching any of these are skipped
+	Exclude []string
+	// file extensions (e.g. pdf) or content types (e.g. video/mp4, video/*), all types if empty
+	Types []string
+	// in bytes, no limit if 0
+	MaxSize int64
+}
+
+func (f FileFilter) IsEmpty() bool {
+	return len(f.Include) == 0 && len(f.Exclude) == 0 && len(f.Types) == 0 && f.MaxSize <= 0
+}
+
+func matchesAny(patterns []string, canvasPath string) bool {
+	canvasPath = strings.ToLower(canvasPath)
+	for _, pattern := range patterns {
+		if ok, _ := doublestar.Match(strings.ToLower(pattern), canvasPath); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func (f FileFilter) matchesType(name string, contentType string) bool {
+	if len(f.Types) == 0 {
+		return true
+	}
+	ext := strings.TrimPrefix(strings.ToLower(path.Ext(name)), ".")
+	contentType = strings.ToLower(contentType)
+	for _, t := range f.Types {
+		t = strings.TrimPrefix(strings.ToLower(strings.TrimSpace(t)), ".")
+		if t == ext || t == contentType {
+			return true
+		}
+		if major, found := strings.CutSuffix(t, "/*"); found && strings.HasPrefix(contentType, major+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// skipsFolder reports whether a folder (and everything in it) is excluded
+func (f FileFilter) skipsFolder(canvasPath string) bool {
+	return canvasPath != "" && matchesAny(f.Exclude, canvasPath)
+}
+
+// SkipsFile reports whether a file is filtered out, including by an excluded parent folder.
+// contentType may be empty if unknown.
+func (f FileFilter) SkipsFile(canvasPath string, contentType string, size int64) bool {
+	if matchesAny(f.Exclude, canvasPath) {
+		return true
+	}
+	for dir := path.Dir(canvasPath); dir != "." && dir != "/"; dir = path.Dir(dir) {
+		if f.skipsFolder(dir) {
+			return true
+		}
+	}
+	if len(f.Include) > 0 && !matchesAny(f.Include, canvasPath) {
+		return true
+	}
+	if f.MaxSize > 0 && size > f.MaxSize {
+		return true
+	}
+	return !f.matchesType(canvasPath, contentType)
+}
+
+// folderCanvasPath returns a folder's path relative to the course's files, "" for the root folder
+func folderCanvasPath(folder *nodes.DirectoryNode) string {
+	_, rel, _ := strings.Cut(folder.FullName, "/")
+	return rel
+}
+
+func fileCanvasPath(folder *nodes.DirectoryNode, file *nodes.FileNode) string {
+	return path.Join(folderCanvasPath(folder), file.Display_name)
+}
+
+// filterTree removes the files and folders skipped by f from the tree
+func filterTree(node *nodes.DirectoryNode, f FileFilter) {
+	if f.IsEmpty() {
+		return
+	}
+	files := make([]*nodes.FileNode, 0, len(node.FileNodes))
+	for _, file := range node.FileNodes {
+		if file != nil && !f.SkipsFile(fileCanvasPath(node, file), file.ContentType, file.Size) {
+			files = append(files, file)
+		}
+	}
+	node.FileNodes = files
+	folders := make([]*nodes.DirectoryNode, 0, len(node.FolderNodes))
+	for _, folder := range node.FolderNodes {
+		if folder != nil && !f.skipsFolder(folderCanvasPath(folder)) {
+			filterTree(folder, f)
+			folders = append(folders, folder)
+		}
+	}
+	node.FolderNodes = folders
+}
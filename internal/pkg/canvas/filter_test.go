@@ -0,0 +1,74 @@
+package canvas
+
+import (
+	"testing"
+
+	"github.com/aidanaden/canvas-sync/internal/pkg/nodes"
+)
+
+func TestFileFilterSkipsFile(t *testing.T) {
+	tests := []struct {
+		name        string
+		filter      FileFilter
+		canvasPath  string
+		contentType string
+		size        int64
+		want        bool
+	}{
+		{"empty filter", FileFilter{}, "Lectures/slides.pdf", "application/pdf", 100, false},
+		{"include match", FileFilter{Include: []string{"Lectures/**"}}, "Lectures/Week 1/slides.pdf", "", 0, false},
+		{"include miss", FileFilter{Include: []string{"Lectures/**"}}, "Tutorials/answers.pdf", "", 0, true},
+		{"include is case insensitive", FileFilter{Include: []string{"lectures/*.PDF"}}, "Lectures/slides.pdf", "", 0, false},
+		{"single star stays in the folder", FileFilter{Include: []string{"Lectures/*.pdf"}}, "Lectures/Week 1/slides.pdf", "", 0, true},
+		{"exclude match", FileFilter{Exclude: []string{"**/*.mp4"}}, "Lectures/Week 1/recording.mp4", "", 0, true},
+		{"excluded parent folder", FileFilter{Exclude: []string{"Lectures/Old"}}, "Lectures/Old/Week 1/slides.pdf", "", 0, true},
+		{"exclude wins over include", FileFilter{Include: []string{"**"}, Exclude: []string{"Solutions/**"}}, "Solutions/answers.pdf", "", 0, true},
+		{"extension", FileFilter{Types: []string{".PDF", "docx"}}, "Lectures/slides.pdf", "", 0, false},
+		{"other extension", FileFilter{Types: []string{"pdf"}}, "Lectures/recording.mp4", "video/mp4", 0, true},
+		{"content type", FileFilter{Types: []string{"video/mp4"}}, "Lectures/recording", "video/mp4", 0, false},
+		{"content type wildcard", FileFilter{Types: []string{"video/*"}}, "Lectures/recording.mov", "video/quicktime", 0, false},
+		{"content type wildcard miss", FileFilter{Types: []string{"video/*"}}, "Lectures/slides.pdf", "application/pdf", 0, true},
+		{"at max size", FileFilter{MaxSize: 100}, "Lectures/slides.pdf", "", 100, false},
+		{"over max size", FileFilter{MaxSize: 100}, "Lectures/slides.pdf", "", 101, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.filter.SkipsFile(tt.canvasPath, tt.contentType, tt.size); got != tt.want {
+				t.Errorf("SkipsFile(%q, %q, %d) = %v, want %v", tt.canvasPath, tt.contentType, tt.size, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFilterTree(t *testing.T) {
+	root := &nodes.DirectoryNode{
+		FullName: "course files",
+		FileNodes: []*nodes.FileNode{
+			{Display_name: "syllabus.pdf", Size: 10},
+			{Display_name: "recording.mp4", Size: 1000},
+			nil,
+		},
+		FolderNodes: []*nodes.DirectoryNode{
+			{
+				FullName:  "course files/Lectures",
+				FileNodes: []*nodes.FileNode{{Display_name: "slides.pdf", Size: 10}},
+			},
+			{
+				FullName:  "course files/Solutions",
+				FileNodes: []*nodes.FileNode{{Display_name: "answers.pdf", Size: 10}},
+			},
+		},
+	}
+
+	filterTree(root, FileFilter{Exclude: []string{"Solutions"}, MaxSize: 100})
+
+	if len(root.FileNodes) != 1 || root.FileNodes[0].Display_name != "syllabus.pdf" {
+		t.Errorf("kept files %v, want only syllabus.pdf", root.FileNodes)
+	}
+	if len(root.FolderNodes) != 1 || root.FolderNodes[0].FullName != "course files/Lectures" {
+		t.Fatalf("kept folders %v, want only Lectures", root.FolderNodes)
+	}
+	if len(root.FolderNodes[0].FileNodes) != 1 {
+		t.Errorf("kept %d files in Lectures, want 1", len(root.FolderNodes[0].FileNodes))
+	}
+}
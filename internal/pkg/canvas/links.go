@@ -0,0 +1,77 @@
+package canvas
+
+import (
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/aidanaden/canvas-sync/internal/pkg/manifest"
+)
+
+// matches canvas file links e.g. /courses/1/files/2/download?wrap=1, /files/2/preview
+var canvasFileLink = regexp.MustCompile(`(?:^|/)files/(\d+)(?:/|$)`)
+
+// courseLinks rewrites links in course content (pages, assignments, ...) so they work offline
+type courseLinks struct {
+	canvasPath *url.URL
+	// local copies of downloaded canvas files by file id
+	files map[int]string
+}
+
+// newCourseLinks indexes the files downloaded by file and module syncs, preferring the files copy
+func (c *CanvasClient) newCourseLinks(m *manifest.Manifest, courseDir string) *courseLinks {
+	links := &courseLinks{canvasPath: c.canvasPath, files: make(map[int]string)}
+	for _, dir := range []string{MODULES_DIR, FILES_DIR} {
+		for id, entry := range m.ByFileID(filepath.Join(courseDir, dir)) {
+			localPath := m.LocalPath(&entry)
+			if _, err := os.Stat(localPath); err == nil {
+				links.files[id] = localPath
+			}
+		}
+	}
+	return links
+}
+
+// fileID returns the id of the canvas file a link points to, 0 if it isn't a canvas file link
+func (l *courseLinks) fileID(link string) int {
+	u, err := url.Parse(link)
+	if err != nil || (u.Host != "" && u.Host != l.canvasPath.Host) {
+		return 0
+	}
+	match := canvasFileLink.FindStringSubmatch(u.Path)
+	if match == nil {
+		return 0
+	}
+	id, _ := strconv.Atoi(match[1])
+	return id
+}
+
+// rewrite returns a function rewriting links in a document saved in dir: canvas file links point to their local
+// copy where one exists, and other links relative to the canvas host are made absolute
+func (l *courseLinks) rewrite(dir string) func(link string) string {
+	return func(link string) string {
+		if localPath, ok := l.files[l.fileID(link)]; ok {
+			return markdownLink(dir, localPath)
+		}
+		u, err := url.Parse(link)
+		if err != nil || u.IsAbs() || u.Host != "" || !strings.HasPrefix(u.Path, "/") {
+			return link
+		}
+		return l.canvasPath.ResolveReference(u).String()
+	}
+}
+
+// with returns a copy of l that also links the given files to their local copies
+func (l *courseLinks) with(files map[int]string) *courseLinks {
+	merged := &courseLinks{canvasPath: l.canvasPath, files: make(map[int]string, len(l.files)+len(files))}
+	for id, localPath := range l.files {
+		merged.files[id] = localPath
+	}
+	for id, localPath := range files {
+		merged.files[id] = localPath
+	}
+	return merged
+}
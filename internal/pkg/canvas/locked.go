@@ -0,0 +1,89 @@
+package canvas
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/aidanaden/canvas-sync/internal/pkg/nodes"
+	"github.com/pterm/pterm"
+)
+
+// fileLocked reports whether a file can't be downloaded by the user (yet)
+func fileLocked(file *nodes.FileNode) bool {
+	return file.Locked || file.LockedForUser || file.HiddenForUser
+}
+
+func folderLocked(folder *nodes.DirectoryNode) bool {
+	return folder.Locked || folder.LockedForUser || folder.HiddenForUser
+}
+
+// collectSyncableFileNodes returns the files under node that can be downloaded, calling onLocked for every
+// locked/hidden file and folder skipped
+func collectSyncableFileNodes(node *nodes.DirectoryNode, onLocked func(path string, unlockAt *time.Time), files []*nodes.FileNode) []*nodes.FileNode {
+	if node == nil {
+		return files
+	}
+	for _, file := range node.FileNodes {
+		if file == nil {
+			continue
+		}
+		if fileLocked(file) {
+			onLocked(file.Directory, file.UnlockAt)
+			continue
+		}
+		files = append(files, file)
+	}
+	for _, folder := range node.FolderNodes {
+		if folder == nil {
+			continue
+		}
+		if folderLocked(folder) {
+			onLocked(folder.Directory, folder.UnlockAt)
+			continue
+		}
+		files = collectSyncableFileNodes(folder, onLocked, files)
+	}
+	return files
+}
+
+// createSyncableDirs creates node's directory and those of the folders under it that aren't locked
+func createSyncableDirs(node *nodes.DirectoryNode) error {
+	if err := os.MkdirAll(node.Directory, 0755); err != nil {
+		return err
+	}
+	for _, folder := range node.FolderNodes {
+		if folder == nil || folderLocked(folder) {
+			continue
+		}
+		if err := createSyncableDirs(folder); err != nil {
+			pterm.Error.Printfln("Error creating folder %s: %s", folder.Name, err.Error())
+		}
+	}
+	return nil
+}
+
+// lockedDirs returns the local directories of locked folders, whose contents may not have been listed
+func lockedDirs(node *nodes.DirectoryNode, dirs []string) []string {
+	for _, folder := range node.FolderNodes {
+		if folder == nil {
+			continue
+		}
+		if folderLocked(folder) {
+			dirs = append(dirs, folder.Directory)
+			continue
+		}
+		dirs = lockedDirs(folder, dirs)
+	}
+	return dirs
+}
+
+func insideAny(dirs []string, path string) bool {
+	for _, dir := range dirs {
+		if strings.HasPrefix(path, dir+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,122 @@
+package canvas
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/aidanaden/canvas-sync/internal/pkg/manifest"
+	"github.com/aidanaden/canvas-sync/internal/pkg/nodes"
+)
+
+// lockedTree returns a tree with a downloadable file, a file locked until unlockAt and a hidden folder
+func lockedTree(c *CanvasClient, dir string, unlockAt time.Time) *nodes.DirectoryNode {
+	fileUrl := c.canvasPath.JoinPath("file").String()
+	return &nodes.DirectoryNode{
+		Directory: dir,
+		FileNodes: []*nodes.FileNode{
+			{ID: 1, Url: fileUrl, UpdatedAt: fileModTime, Directory: filepath.Join(dir, "notes.pdf")},
+			{ID: 2, Url: fileUrl, UpdatedAt: fileModTime, Directory: filepath.Join(dir, "answers.pdf"), LockedForUser: true, UnlockAt: &unlockAt},
+		},
+		FolderNodes: []*nodes.DirectoryNode{{
+			Name:          "Solutions",
+			Directory:     filepath.Join(dir, "Solutions"),
+			HiddenForUser: true,
+			FileNodes: []*nodes.FileNode{
+				{ID: 3, Url: fileUrl, UpdatedAt: fileModTime, Directory: filepath.Join(dir, "Solutions", "week1.pdf")},
+			},
+		}},
+	}
+}
+
+func TestRecursiveNodeReportsLockedItems(t *testing.T) {
+	unlockAt := time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC)
+	syncs := map[string]func(c *CanvasClient, root *nodes.DirectoryNode, m *manifest.Manifest, report *SyncReport) error{
+		"create": func(c *CanvasClient, root *nodes.DirectoryNode, m *manifest.Manifest, report *SyncReport) error {
+			return c.RecursiveCreateNode(context.Background(), root, m, report, func(int) {})
+		},
+		"update": func(c *CanvasClient, root *nodes.DirectoryNode, m *manifest.Manifest, report *SyncReport) error {
+			return c.RecursiveUpdateNode(context.Background(), root, m, UpdateOptions{}, report, func(int) {})
+		},
+	}
+	for name, sync := range syncs {
+		t.Run(name, func(t *testing.T) {
+			c := newAPIClient(t, http.HandlerFunc(serveFile))
+			dir := t.TempDir()
+			m, err := manifest.Load(dir, 1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			root := lockedTree(c, dir, unlockAt)
+			report := &SyncReport{}
+
+			if err := sync(c, root, m, report); err != nil {
+				t.Fatal(err)
+			}
+
+			sort.Slice(report.Locked, func(i, j int) bool { return report.Locked[i].Path < report.Locked[j].Path })
+			if len(report.Locked) != 2 {
+				t.Fatalf("reported %v as locked, want the locked file and the hidden folder", report.Locked)
+			}
+			if locked := report.Locked[0]; locked.Path != filepath.Join(dir, "Solutions") || locked.UnlockAt != nil {
+				t.Errorf("reported %v, want the hidden folder without an unlock date", locked)
+			}
+			if locked := report.Locked[1]; locked.Path != filepath.Join(dir, "answers.pdf") || locked.UnlockAt == nil || !locked.UnlockAt.Equal(unlockAt) {
+				t.Errorf("reported %v, want the locked file unlocking at %s", locked, unlockAt)
+			}
+			if len(report.Downloaded) != 1 || report.Downloaded[0] != filepath.Join(dir, "notes.pdf") {
+				t.Errorf("downloaded %v, want only the unlocked file", report.Downloaded)
+			}
+			for _, path := range []string{filepath.Join(dir, "answers.pdf"), filepath.Join(dir, "Solutions")} {
+				if _, err := os.Stat(path); !os.IsNotExist(err) {
+					t.Errorf("%s was created", path)
+				}
+			}
+		})
+	}
+}
+
+func TestRecursiveUpdateNodePicksUpUnlockedFiles(t *testing.T) {
+	c := newAPIClient(t, http.HandlerFunc(serveFile))
+	dir := t.TempDir()
+	m, err := manifest.Load(dir, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	root := lockedTree(c, dir, time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC))
+	update := func() *SyncReport {
+		t.Helper()
+		report := &SyncReport{}
+		if err := c.RecursiveUpdateNode(context.Background(), root, m, UpdateOptions{}, report, func(int) {}); err != nil {
+			t.Fatal(err)
+		}
+		return report
+	}
+
+	update()
+	lockedPath := filepath.Join(dir, "answers.pdf")
+	if m.Get(lockedPath) != nil {
+		t.Fatal("the locked file was recorded in the manifest")
+	}
+
+	root.FileNodes[1].LockedForUser = false
+	root.FileNodes[1].UnlockAt = nil
+	root.FolderNodes[0].HiddenForUser = false
+	report := update()
+
+	sort.Strings(report.Downloaded)
+	want := []string{filepath.Join(dir, "Solutions", "week1.pdf"), lockedPath}
+	if len(report.Downloaded) != 2 || report.Downloaded[0] != want[0] || report.Downloaded[1] != want[1] {
+		t.Errorf("downloaded %v once unlocked, want %v", report.Downloaded, want)
+	}
+	if len(report.Locked) != 0 {
+		t.Errorf("reported %v as locked once unlocked", report.Locked)
+	}
+	if entry := m.Get(lockedPath); entry == nil || entry.FileID != 2 {
+		t.Errorf("manifest entry for %s is %v, want file 2", lockedPath, entry)
+	}
+}
@@ -0,0 +1,242 @@
+package canvas
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/aidanaden/canvas-sync/internal/pkg/nodes"
+	"github.com/aidanaden/canvas-sync/internal/pkg/utils"
+)
+
+const (
+	MODULE_ITEM_FILE         = "File"
+	MODULE_ITEM_SUBHEADER    = "SubHeader"
+	MODULE_ITEM_EXTERNAL_URL = "ExternalUrl"
+	MODULES_README           = "README.md"
+)
+
+func extractModulesFromString(rawJson string) ([]nodes.ModuleNode, error) {
+	var modules []nodes.ModuleNode
+	if err := json.Unmarshal([]byte(rawJson), &modules); err != nil {
+		return nil, fmt.Errorf("failed to parse modules: %w", err)
+	}
+	return modules, nil
+}
+
+func extractModuleItemsFromString(rawJson string) ([]nodes.ModuleItemNode, error) {
+	var items []nodes.ModuleItemNode
+	if err := json.Unmarshal([]byte(rawJson), &items); err != nil {
+		return nil, fmt.Errorf("failed to parse module items: %w", err)
+	}
+	return items, nil
+}
+
+// GetCourseModules returns a course's modules with their items, in module order
+func (c *CanvasClient) GetCourseModules(ctx context.Context, courseId int) ([]nodes.ModuleNode, error) {
+	modulesUrl := c.getCourseListUrl(courseId, "modules")
+	q := modulesUrl.Query()
+	q.Add("include[]", "items")
+	q.Add("include[]", "content_details")
+	modulesUrl.RawQuery = q.Encode()
+	modules, err := getPaginated(ctx, c, modulesUrl, extractModulesFromString)
+	if err != nil {
+		return nil, err
+	}
+	for i := range modules {
+		// canvas leaves out the items of modules with too many of them
+		if modules[i].Items != nil || modules[i].ItemsCount == 0 || modules[i].ItemsUrl == "" {
+			continue
+		}
+		itemsUrl, err := url.Parse(modules[i].ItemsUrl)
+		if err != nil {
+			return nil, err
+		}
+		q := itemsUrl.Query()
+		q.Add("include[]", "content_details")
+		itemsUrl.RawQuery = q.Encode()
+		if modules[i].Items, err = getPaginated(ctx, c, *itemsUrl, extractModuleItemsFromString); err != nil {
+			return nil, err
+		}
+	}
+	sort.SliceStable(modules, func(i, j int) bool {
+		return modules[i].Position < modules[j].Position
+	})
+	for i := range modules {
+		sort.SliceStable(modules[i].Items, func(a, b int) bool {
+			return modules[i].Items[a].Position < modules[i].Items[b].Position
+		})
+	}
+	return modules, nil
+}
+
+func (c *CanvasClient) getModuleFileNode(ctx context.Context, item nodes.ModuleItemNode) (*nodes.FileNode, error) {
+	fileJson, _, err := c.get(ctx, item.Url)
+	if err != nil {
+		return nil, err
+	}
+	var file *nodes.FileNode
+	if err := json.Unmarshal(fileJson, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse file %s: %w", item.Title, err)
+	}
+	return file, nil
+}
+
+// ModuleTree is a course's module files as a tree of "NN - Module name" folders
+type ModuleTree struct {
+	Root    *nodes.DirectoryNode
+	modules []nodes.ModuleNode
+	// file nodes by module item id
+	files map[int]*nodes.FileNode
+}
+
+func moduleFolderName(index int, module nodes.ModuleNode) string {
+	return fmt.Sprintf("%02d - %s", index+1, module.Name)
+}
+
+// getCourseFilesById returns the files of a course's files tab by id, nil if the tab is hidden from the user
+func (c *CanvasClient) getCourseFilesById(ctx context.Context, courseId int) (map[int]*nodes.FileNode, error) {
+	files, err := getPaginated(ctx, c, c.getCourseListUrl(courseId, "files"), extractFilesFromString)
+	if filesUnavailable(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	byId := make(map[int]*nodes.FileNode, len(files))
+	for _, file := range files {
+		byId[file.ID] = file
+	}
+	return byId, nil
+}
+
+// GetCourseModuleTree returns the file items of a course's modules not skipped by filter, as a tree rooted at dir.
+// Files are looked up in the course's file listing, only those missing from it (e.g. hidden from the files tab) are
+// fetched one by one. Items linking to files deleted from canvas are added to report as missing.
+func (c *CanvasClient) GetCourseModuleTree(ctx context.Context, courseId int, dir string, filter FileFilter, report *SyncReport) (*ModuleTree, error) {
+	modules, err := c.GetCourseModules(ctx, courseId)
+	if err != nil {
+		return nil, err
+	}
+	courseFiles, err := c.getCourseFilesById(ctx, courseId)
+	if err != nil {
+		return nil, err
+	}
+	tree := &ModuleTree{
+		Root:    &nodes.DirectoryNode{Name: MODULES_DIR, FullName: MODULES_DIR},
+		modules: modules,
+		files:   make(map[int]*nodes.FileNode),
+	}
+	type missingItem struct {
+		folder *nodes.DirectoryNode
+		title  string
+		err    error
+	}
+	missing := make([]missingItem, 0)
+	for i, module := range modules {
+		folder := &nodes.DirectoryNode{ID: module.ID, Name: moduleFolderName(i, module), UnlockAt: module.UnlockAt}
+		folder.FullName = MODULES_DIR + "/" + folder.Name
+		for _, item := range module.Items {
+			if item.Type != MODULE_ITEM_FILE {
+				continue
+			}
+			var file *nodes.FileNode
+			if listed, ok := courseFiles[item.ContentID]; ok {
+				// copied as the same file can be an item of several modules
+				copied := *listed
+				file = &copied
+			} else if file, err = c.getModuleFileNode(ctx, item); errors.Is(err, ErrUnauthorized) || errors.Is(err, ErrForbidden) {
+				// files of locked modules can't be fetched until they unlock
+				file = &nodes.FileNode{ID: item.ContentID, Display_name: item.Title, LockedForUser: true}
+			} else if errors.Is(err, ErrNotFound) {
+				// the item still links to a file deleted from canvas
+				missing = append(missing, missingItem{folder: folder, title: item.Title, err: err})
+				continue
+			} else if err != nil {
+				return nil, err
+			}
+			if item.ContentDetails.LockedForUser {
+				file.LockedForUser = true
+			}
+			if file.LockedForUser && file.UnlockAt == nil {
+				file.UnlockAt = item.ContentDetails.UnlockAt
+				if file.UnlockAt == nil {
+					file.UnlockAt = module.UnlockAt
+				}
+			}
+			// moving a file between modules counts as a move between folders
+			file.FolderID = module.ID
+			folder.FileNodes = append(folder.FileNodes, file)
+			tree.files[item.ID] = file
+		}
+		tree.Root.FolderNodes = append(tree.Root.FolderNodes, folder)
+	}
+	setTreeDirectories(tree.Root, dir)
+	for _, item := range missing {
+		report.AddMissing(filepath.Join(item.folder.Directory, utils.SanitiseFilename(item.title)), item.err)
+	}
+	filterTree(tree.Root, filter)
+	return tree, nil
+}
+
+// markdownLink links to a local path relative to base, escaping it so names with spaces work
+func markdownLink(base string, path string) string {
+	rel, err := filepath.Rel(base, path)
+	if err != nil {
+		return path
+	}
+	segments := strings.Split(filepath.ToSlash(rel), "/")
+	for i := range segments {
+		segments[i] = url.PathEscape(segments[i])
+	}
+	return strings.Join(segments, "/")
+}
+
+// WriteReadme writes a README listing every module's items in order, linking downloaded files locally and
+// everything else (pages, links, assignments, ...) to canvas
+func (t *ModuleTree) WriteReadme() error {
+	var b strings.Builder
+	b.WriteString("# Modules\n")
+	for i, module := range t.modules {
+		fmt.Fprintf(&b, "\n## %s\n\n", moduleFolderName(i, module))
+		if len(module.Items) == 0 {
+			b.WriteString("No items\n")
+			continue
+		}
+		for _, item := range module.Items {
+			indent := strings.Repeat("  ", item.Indent)
+			switch item.Type {
+			case MODULE_ITEM_SUBHEADER:
+				fmt.Fprintf(&b, "%s- **%s**\n", indent, item.Title)
+				continue
+			case MODULE_ITEM_FILE:
+				file := t.files[item.ID]
+				if file != nil {
+					if _, err := os.Stat(file.Directory); err == nil {
+						fmt.Fprintf(&b, "%s- [%s](%s)\n", indent, item.Title, markdownLink(t.Root.Directory, file.Directory))
+						continue
+					}
+				}
+			}
+			link := item.HtmlUrl
+			if item.Type == MODULE_ITEM_EXTERNAL_URL && item.ExternalUrl != "" {
+				link = item.ExternalUrl
+			}
+			locked := ""
+			if item.ContentDetails.LockedForUser {
+				locked = " (locked)"
+			}
+			fmt.Fprintf(&b, "%s- %s: [%s](%s)%s\n", indent, item.Type, item.Title, link, locked)
+		}
+	}
+	if err := os.MkdirAll(t.Root.Directory, 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(t.Root.Directory, MODULES_README), []byte(b.String()), 0644)
+}
@@ -0,0 +1,54 @@
+package canvas
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+)
+
+func TestGetCourseModuleTreeSkipsDeletedFiles(t *testing.T) {
+	var singleGets atomic.Int32
+	mux := http.NewServeMux()
+	var serverUrl string
+	mux.HandleFunc("/api/v1/courses/1/modules", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `[{"id": 10, "name": "Week 1", "position": 1, "items_count": 3, "items": [
+			{"id": 100, "title": "slides.pdf", "position": 1, "type": "File", "content_id": 5, "url": "%[1]s/api/v1/courses/1/files/5"},
+			{"id": 101, "title": "deleted.pdf", "position": 2, "type": "File", "content_id": 6, "url": "%[1]s/api/v1/courses/1/files/6"},
+			{"id": 102, "title": "hidden.pdf", "position": 3, "type": "File", "content_id": 7, "url": "%[1]s/api/v1/courses/1/files/7"}
+		]}]`, serverUrl)
+	})
+	mux.HandleFunc("/api/v1/courses/1/files", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[{"id": 5, "display_name": "slides.pdf", "size": 10}]`)
+	})
+	mux.HandleFunc("/api/v1/courses/1/files/6", func(w http.ResponseWriter, r *http.Request) {
+		singleGets.Add(1)
+		http.Error(w, `{"errors": [{"message": "The specified resource does not exist."}]}`, http.StatusNotFound)
+	})
+	mux.HandleFunc("/api/v1/courses/1/files/7", func(w http.ResponseWriter, r *http.Request) {
+		singleGets.Add(1)
+		fmt.Fprint(w, `{"id": 7, "display_name": "hidden.pdf", "size": 20}`)
+	})
+	c := newAPIClient(t, mux)
+	serverUrl = c.canvasPath.String()
+
+	dir := filepath.Join(t.TempDir(), MODULES_DIR)
+	report := &SyncReport{}
+	tree, err := c.GetCourseModuleTree(context.Background(), 1, dir, FileFilter{}, report)
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := tree.Root.FolderNodes[0].FileNodes
+	if len(files) != 2 || files[0].ID != 5 || files[1].ID != 7 {
+		t.Fatalf("got module files %v, want files 5 and 7", files)
+	}
+	if got := singleGets.Load(); got != 2 {
+		t.Errorf("fetched %d files one by one, want only the 2 missing from the file listing", got)
+	}
+	want := filepath.Join(dir, "01 - Week 1", "deleted.pdf")
+	if len(report.Missing) != 1 || report.Missing[0].Path != want {
+		t.Errorf("reported missing %v, want %s", report.Missing, want)
+	}
+}
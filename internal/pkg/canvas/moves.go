@@ -0,0 +1,94 @@
+package canvas
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/aidanaden/canvas-sync/internal/pkg/manifest"
+	"github.com/aidanaden/canvas-sync/internal/pkg/nodes"
+	"github.com/aidanaden/canvas-sync/internal/pkg/utils"
+)
+
+// collectFileNodes flattens every file in the tree
+func collectFileNodes(node *nodes.DirectoryNode, files []*nodes.FileNode) []*nodes.FileNode {
+	if node == nil {
+		return files
+	}
+	for _, file := range node.FileNodes {
+		if file != nil {
+			files = append(files, file)
+		}
+	}
+	for _, folder := range node.FolderNodes {
+		files = collectFileNodes(folder, files)
+	}
+	return files
+}
+
+// legacyPath returns where a file was downloaded before names were sanitised, with the canvas names used as is
+func legacyPath(root *nodes.DirectoryNode, file *nodes.FileNode) string {
+	if file.CanvasPath == "" {
+		return ""
+	}
+	return filepath.Join(root.Directory, filepath.FromSlash(file.CanvasPath))
+}
+
+type plannedMove struct {
+	node          *nodes.FileNode
+	from          string
+	folderChanged bool
+}
+
+// planMoves finds local copies of files that were renamed or moved on canvas, matched by file id, and untracked
+// copies still at their unsanitised path
+func planMoves(root *nodes.DirectoryNode, m *manifest.Manifest) []plannedMove {
+	downloaded := m.ByFileID(root.Directory)
+	files := collectFileNodes(root, nil)
+	// the same file can appear more than once (e.g. in two modules), don't move a copy that's still wanted
+	wanted := make(map[string]bool, len(files))
+	for _, file := range files {
+		wanted[file.Directory] = true
+	}
+	moves := make([]plannedMove, 0)
+	for _, file := range files {
+		var oldPath string
+		folderChanged := false
+		if entry, ok := downloaded[file.ID]; ok {
+			oldPath = m.LocalPath(&entry)
+			folderChanged = entry.FolderID != file.FolderID
+		} else if oldPath = legacyPath(root, file); oldPath == "" || m.Get(oldPath) != nil {
+			continue
+		}
+		if wanted[oldPath] {
+			continue
+		}
+		// the old copy is gone, or something already exists at the new path: leave it to the update
+		if _, err := os.Stat(oldPath); err != nil {
+			continue
+		}
+		if _, err := os.Stat(file.Directory); err == nil {
+			continue
+		}
+		moves = append(moves, plannedMove{node: file, from: oldPath, folderChanged: folderChanged})
+	}
+	return moves
+}
+
+// MirrorMoves renames local copies of files that were renamed or moved on canvas,
+// so they aren't downloaded a second time under their new path
+func MirrorMoves(root *nodes.DirectoryNode, m *manifest.Manifest, report *SyncReport) {
+	for _, move := range planMoves(root, m) {
+		if err := os.MkdirAll(filepath.Dir(move.node.Directory), 0755); err != nil {
+			report.AddFailed(move.node.Directory, err)
+			continue
+		}
+		if err := os.Rename(move.from, move.node.Directory); err != nil {
+			report.AddFailed(move.node.Directory, err)
+			continue
+		}
+		m.Move(move.from, move.node.Directory)
+		report.AddMoved(move.from, move.node.Directory, move.folderChanged)
+		// clean up folders that were renamed on canvas
+		utils.RemoveEmptyParents(filepath.Dir(move.from), root.Directory)
+	}
+}
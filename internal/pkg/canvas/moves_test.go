@@ -0,0 +1,59 @@
+package canvas
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/aidanaden/canvas-sync/internal/pkg/manifest"
+	"github.com/aidanaden/canvas-sync/internal/pkg/nodes"
+)
+
+func TestMirrorMovesAdoptsUnsanitisedCopies(t *testing.T) {
+	courseDir := t.TempDir()
+	filesDir := filepath.Join(courseDir, FILES_DIR)
+	root := &nodes.DirectoryNode{
+		ID:       1,
+		FullName: "course files",
+		FolderNodes: []*nodes.DirectoryNode{{
+			ID:        2,
+			Name:      "Week 1: Intro",
+			FullName:  "course files/Week 1: Intro",
+			FileNodes: []*nodes.FileNode{{ID: 10, FolderID: 2, Display_name: "notes?.pdf"}},
+		}},
+		FileNodes: []*nodes.FileNode{{ID: 11, FolderID: 1, Display_name: "syllabus.pdf"}},
+	}
+	setTreeDirectories(root, filesDir)
+	m, err := manifest.Load(courseDir, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// downloaded by a version that neither sanitised names nor kept a manifest
+	legacy := filepath.Join(filesDir, "Week 1: Intro", "notes?.pdf")
+	unchanged := filepath.Join(filesDir, "syllabus.pdf")
+	for _, path := range []string{legacy, unchanged} {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("content"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	report := &SyncReport{}
+	MirrorMoves(root, m, report)
+	notes := root.FolderNodes[0].FileNodes[0]
+	if _, err := os.Stat(notes.Directory); err != nil {
+		t.Errorf("unsanitised copy wasn't moved to %s", notes.Directory)
+	}
+	if _, err := os.Stat(filepath.Dir(legacy)); !os.IsNotExist(err) {
+		t.Error("the empty unsanitised folder was left behind")
+	}
+	if _, err := os.Stat(unchanged); err != nil {
+		t.Error("a file already at its path was moved")
+	}
+	if len(report.Moved) != 1 {
+		t.Errorf("reported %d moves, want 1", len(report.Moved))
+	}
+}
@@ -0,0 +1,112 @@
+package canvas
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/aidanaden/canvas-sync/internal/pkg/manifest"
+	"github.com/aidanaden/canvas-sync/internal/pkg/nodes"
+)
+
+const PAGES_DIR = "pages"
+
+func extractPagesFromString(rawJson string) ([]nodes.PageNode, error) {
+	var pages []nodes.PageNode
+	if err := json.Unmarshal([]byte(rawJson), &pages); err != nil {
+		return nil, fmt.Errorf("failed to parse pages: %w", err)
+	}
+	return pages, nil
+}
+
+// GetCoursePages returns a course's pages without their bodies
+func (c *CanvasClient) GetCoursePages(ctx context.Context, courseId int) ([]nodes.PageNode, error) {
+	return getPaginated(ctx, c, c.getCourseListUrl(courseId, "pages"), extractPagesFromString)
+}
+
+// GetCoursePage returns a single page with its body, by its url slug
+func (c *CanvasClient) GetCoursePage(ctx context.Context, courseId int, pageUrl string) (*nodes.PageNode, error) {
+	u := c.getCourseListUrl(courseId, "pages/"+url.PathEscape(pageUrl))
+	pageJson, _, err := c.get(ctx, u.String())
+	if err != nil {
+		return nil, err
+	}
+	var page *nodes.PageNode
+	if err := json.Unmarshal(pageJson, &page); err != nil {
+		return nil, fmt.Errorf("failed to parse page %s: %w", pageUrl, err)
+	}
+	return page, nil
+}
+
+// PagesSource syncs the course's wiki pages as <title>.html and <title>.md, tracked in the manifest by page id
+var PagesSource = DocumentSource{
+	Dir: PAGES_DIR,
+	Sync: func(ctx context.Context, c *CanvasClient, course nodes.CourseNode, courseDir string, m *manifest.Manifest, opts DocumentOptions, report *SyncReport) (int, error) {
+		return c.SyncCoursePages(ctx, course.ID, courseDir, m, opts, report)
+	},
+}
+
+// SyncCoursePages writes every page of a course into <courseDir>/pages, returning the number of pages written
+func (c *CanvasClient) SyncCoursePages(ctx context.Context, courseId int, courseDir string, m *manifest.Manifest, opts DocumentOptions, report *SyncReport) (int, error) {
+	pages, err := c.GetCoursePages(ctx, courseId)
+	if err != nil {
+		return 0, err
+	}
+	dir := filepath.Join(courseDir, PAGES_DIR)
+	links := c.newCourseLinks(m, courseDir)
+	synced := m.ByFileID(dir)
+	claim := claimNames()
+	sort.SliceStable(pages, func(i, j int) bool {
+		return pages[i].PageID < pages[j].PageID
+	})
+	written := 0
+	for _, page := range pages {
+		if ctx.Err() != nil {
+			return written, ctx.Err()
+		}
+		base := filepath.Join(dir, claim(page.Title, page.PageID))
+		htmlPath := base + ".html"
+		if page.LockedForUser {
+			var unlockAt *time.Time
+			if page.LockInfo != nil {
+				unlockAt = page.LockInfo.UnlockAt
+			}
+			report.AddLocked(htmlPath, unlockAt)
+			continue
+		}
+		entry, ok := synced[page.PageID]
+		if ok && m.LocalPath(&entry) != htmlPath {
+			// renamed on canvas
+			oldPath := m.LocalPath(&entry)
+			removeDocument(oldPath)
+			m.Remove(oldPath)
+			report.AddMoved(oldPath, htmlPath, false)
+		} else if ok && opts.OnlyChanged && entry.UpdatedAt.Equal(page.UpdatedAt) && documentExists(htmlPath) {
+			continue
+		}
+		full, err := c.GetCoursePage(ctx, courseId, page.Url)
+		if err != nil {
+			report.AddFailed(htmlPath, err)
+			continue
+		}
+		hash, size, err := writeDocument(base, full.Title, full.Body, links)
+		if err != nil {
+			report.AddFailed(htmlPath, err)
+			continue
+		}
+		m.Put(htmlPath, manifest.Entry{
+			FileID:     page.PageID,
+			Size:       size,
+			UpdatedAt:  full.UpdatedAt,
+			CanvasPath: PAGES_DIR + "/" + page.Url,
+			Hash:       hash,
+		})
+		report.AddDownloaded(htmlPath)
+		written++
+	}
+	return written, nil
+}
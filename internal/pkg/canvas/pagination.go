@@ -0,0 +1,62 @@
+package canvas
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+var PER_PAGE = 100
+
+// nextPageUrl returns the rel="next" url from a canvas Link header (RFC 5988), empty if on the last page
+func nextPageUrl(header http.Header) string {
+	for _, rawLinks := range header.Values("Link") {
+		for _, link := range strings.Split(rawLinks, ",") {
+			segments := strings.Split(link, ";")
+			if len(segments) < 2 {
+				continue
+			}
+			linkUrl := strings.Trim(strings.TrimSpace(segments[0]), "<>")
+			for _, param := range segments[1:] {
+				name, value, found := strings.Cut(strings.TrimSpace(param), "=")
+				if !found || !strings.EqualFold(strings.TrimSpace(name), "rel") {
+					continue
+				}
+				// rel can hold several space separated relations e.g. rel="next last"
+				for _, rel := range strings.Fields(strings.Trim(strings.TrimSpace(value), `"`)) {
+					if strings.EqualFold(rel, "next") {
+						return linkUrl
+					}
+				}
+			}
+		}
+	}
+	return ""
+}
+
+// getPaginated fetches every page of a canvas list endpoint, following the Link header until no next page remains
+func getPaginated[T any](ctx context.Context, c *CanvasClient, listUrl url.URL, extract func(rawJson string) ([]T, error)) ([]T, error) {
+	q := listUrl.Query()
+	if q.Get("per_page") == "" {
+		q.Set("per_page", strconv.Itoa(PER_PAGE))
+	}
+	listUrl.RawQuery = q.Encode()
+
+	var all []T
+	next := listUrl.String()
+	for next != "" {
+		body, header, err := c.get(ctx, next)
+		if err != nil {
+			return nil, err
+		}
+		next = nextPageUrl(header)
+		items, err := extract(string(body))
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, items...)
+	}
+	return all, nil
+}
@@ -0,0 +1,118 @@
+package canvas
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+func TestNextPageUrl(t *testing.T) {
+	tests := []struct {
+		name  string
+		links []string
+		want  string
+	}{
+		{"no header", nil, ""},
+		{
+			"canvas header",
+			[]string{`<https://canvas.example.com/api/v1/courses?page=1&per_page=10>; rel="current",<https://canvas.example.com/api/v1/courses?page=2&per_page=10>; rel="next",<https://canvas.example.com/api/v1/courses?page=1&per_page=10>; rel="first"`},
+			"https://canvas.example.com/api/v1/courses?page=2&per_page=10",
+		},
+		{"last page", []string{`<https://a/?page=1>; rel="first", <https://a/?page=3>; rel="last"`}, ""},
+		{"unquoted", []string{`<https://a/?page=2>; rel=next`}, "https://a/?page=2"},
+		{"case-insensitive", []string{`<https://a/?page=2>; REL="Next"`}, "https://a/?page=2"},
+		{"spaces around parameters", []string{` <https://a/?page=2> ;  rel = "next" `}, "https://a/?page=2"},
+		{"other parameters first", []string{`<https://a/?page=2>; title="page 2"; rel="next"`}, "https://a/?page=2"},
+		{"several relations", []string{`<https://a/?page=2>; rel="next last"`}, "https://a/?page=2"},
+		{"relation as a substring", []string{`<https://a/?page=2>; rel="nextish"`}, ""},
+		{"split across headers", []string{`<https://a/?page=1>; rel="current"`, `<https://a/?page=2>; rel="next"`}, "https://a/?page=2"},
+		{"bookmark page", []string{`<https://a/?page=bookmark:WzEsMl0>; rel="next"`}, "https://a/?page=bookmark:WzEsMl0"},
+		{"missing parameters", []string{`<https://a/?page=2>`}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header := http.Header{}
+			for _, link := range tt.links {
+				header.Add("Link", link)
+			}
+			if got := nextPageUrl(header); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetPaginated(t *testing.T) {
+	var perPage []string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v1/items", func(w http.ResponseWriter, r *http.Request) {
+		perPage = append(perPage, r.URL.Query().Get("per_page"))
+		page, err := strconv.Atoi(r.URL.Query().Get("page"))
+		if err != nil {
+			page = 1
+		}
+		if page < 3 {
+			next := *r.URL
+			q := next.Query()
+			q.Set("page", strconv.Itoa(page+1))
+			next.RawQuery = q.Encode()
+			w.Header().Set("Link", fmt.Sprintf(`<http://%s%s>; rel="next"`, r.Host, next.String()))
+		}
+		fmt.Fprintf(w, `[%d]`, page)
+	})
+	c := newAPIClient(t, mux)
+	extract := func(rawJson string) ([]int, error) {
+		var items []int
+		return items, json.Unmarshal([]byte(rawJson), &items)
+	}
+
+	got, err := getPaginated(context.Background(), c, *c.apiPath.JoinPath("items"), extract)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []int{1, 2, 3}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	if want := []string{fmt.Sprint(PER_PAGE), fmt.Sprint(PER_PAGE), fmt.Sprint(PER_PAGE)}; !reflect.DeepEqual(perPage, want) {
+		t.Errorf("requested per_page %v, want %v", perPage, want)
+	}
+
+	// an explicit page size is kept
+	perPage = nil
+	listUrl := c.apiPath.JoinPath("items")
+	listUrl.RawQuery = "per_page=5"
+	if _, err := getPaginated(context.Background(), c, *listUrl, extract); err != nil {
+		t.Fatal(err)
+	}
+	if perPage[0] != "5" {
+		t.Errorf("requested per_page %s, want 5", perPage[0])
+	}
+}
+
+func TestGetPaginatedError(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v1/items", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("page") == "2" {
+			http.Error(w, `{"message": "failed"}`, http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Link", fmt.Sprintf(`<http://%s/api/v1/items?page=2>; rel="next"`, r.Host))
+		fmt.Fprint(w, `[1]`)
+	})
+	c := newAPIClient(t, mux)
+
+	got, err := getPaginated(context.Background(), c, *c.apiPath.JoinPath("items"), func(rawJson string) ([]int, error) {
+		var items []int
+		return items, json.Unmarshal([]byte(rawJson), &items)
+	})
+	if err == nil {
+		t.Fatal("expected the second page's error")
+	}
+	if got != nil {
+		t.Errorf("got %v, want no items from a partial listing", got)
+	}
+}
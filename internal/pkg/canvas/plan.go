@@ -0,0 +1,199 @@
+package canvas
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/aidanaden/canvas-sync/internal/pkg/manifest"
+	"github.com/aidanaden/canvas-sync/internal/pkg/nodes"
+	"github.com/aidanaden/canvas-sync/internal/pkg/utils"
+)
+
+type PlanAction string
+
+const (
+	PLAN_CREATE    PlanAction = "create"
+	PLAN_OVERWRITE PlanAction = "overwrite"
+	PLAN_RENAME    PlanAction = "rename"
+	PLAN_PRUNE     PlanAction = "prune"
+	// deleted from canvas but left in place without --prune
+	PLAN_REMOVED PlanAction = "removed"
+	// locked or hidden, skipped until unlocked
+	PLAN_LOCKED PlanAction = "locked"
+)
+
+const (
+	PLAN_FORMAT_TREE = "tree"
+	PLAN_FORMAT_JSON = "json"
+)
+
+type PlannedFile struct {
+	Action PlanAction `json:"action"`
+	Path   string     `json:"path"`
+	// previous path of renamed files
+	From string `json:"from,omitempty"`
+	Size int64  `json:"size"`
+	// when locked files unlock, if known
+	UnlockAt *time.Time `json:"unlock_at,omitempty"`
+}
+
+// CoursePlan is what a sync would do for a course, computed without writing anything
+type CoursePlan struct {
+	Course string        `json:"course"`
+	Files  []PlannedFile `json:"files"`
+	// bytes that would be downloaded
+	DownloadSize int64 `json:"download_size"`
+	root         *nodes.DirectoryNode
+	byNode       map[*nodes.FileNode]PlannedFile
+}
+
+func newCoursePlan(course string, root *nodes.DirectoryNode) *CoursePlan {
+	return &CoursePlan{
+		Course: course,
+		Files:  make([]PlannedFile, 0),
+		root:   root,
+		byNode: make(map[*nodes.FileNode]PlannedFile),
+	}
+}
+
+func (p *CoursePlan) add(node *nodes.FileNode, planned PlannedFile) {
+	p.Files = append(p.Files, planned)
+	if planned.Action == PLAN_CREATE || planned.Action == PLAN_OVERWRITE {
+		p.DownloadSize += planned.Size
+	}
+	if node != nil {
+		p.byNode[node] = planned
+	}
+}
+
+func (p *CoursePlan) addDownload(node *nodes.FileNode) {
+	action := PLAN_CREATE
+	if _, err := os.Stat(node.Directory); err == nil {
+		action = PLAN_OVERWRITE
+	}
+	p.add(node, PlannedFile{Action: action, Path: node.Directory, Size: node.Size})
+}
+
+func (p *CoursePlan) addLocked(path string, unlockAt *time.Time) {
+	p.add(nil, PlannedFile{Action: PLAN_LOCKED, Path: path, UnlockAt: unlockAt})
+}
+
+// PlanCreate computes what RecursiveCreateNode would download
+func PlanCreate(course string, root *nodes.DirectoryNode) *CoursePlan {
+	plan := newCoursePlan(course, root)
+	for _, file := range collectSyncableFileNodes(root, plan.addLocked, nil) {
+		plan.addDownload(file)
+	}
+	return plan
+}
+
+// PlanUpdate computes what MirrorMoves, PruneRemoved and RecursiveUpdateNode would do. m is only changed in memory
+// and must not be saved afterwards.
+func PlanUpdate(course string, root *nodes.DirectoryNode, m *manifest.Manifest, opts UpdateOptions, prune bool, filter FileFilter) (*CoursePlan, error) {
+	plan := newCoursePlan(course, root)
+	renamed := make(map[*nodes.FileNode]bool)
+	for _, move := range planMoves(root, m) {
+		plan.add(move.node, PlannedFile{Action: PLAN_RENAME, Path: move.node.Directory, From: move.from, Size: move.node.Size})
+		renamed[move.node] = true
+	}
+	removed, _ := planRemoved(root, m, filter)
+	for _, localPath := range removed {
+		action := PLAN_REMOVED
+		if prune {
+			action = PLAN_PRUNE
+		}
+		var size int64
+		if info, err := os.Stat(localPath); err == nil {
+			size = info.Size()
+		}
+		plan.add(nil, PlannedFile{Action: action, Path: localPath, Size: size})
+	}
+	for _, file := range collectSyncableFileNodes(root, plan.addLocked, nil) {
+		if renamed[file] {
+			continue
+		}
+		download, err := needsDownload(file, m, opts)
+		if err != nil {
+			return nil, err
+		}
+		if download {
+			plan.addDownload(file)
+		}
+	}
+	return plan, nil
+}
+
+func (p *CoursePlan) label(file *nodes.FileNode) string {
+	planned, ok := p.byNode[file]
+	if !ok {
+		return ""
+	}
+	if planned.Action == PLAN_RENAME {
+		return fmt.Sprintf("[%s from %s]", planned.Action, planned.From)
+	}
+	return fmt.Sprintf("[%s, %s]", planned.Action, utils.FormatSize(planned.Size))
+}
+
+func (p *CoursePlan) printTree(w io.Writer) {
+	fmt.Fprintf(w, "%s:\n", p.Course)
+	if len(p.Files) == 0 {
+		fmt.Fprintln(w, "  nothing to do")
+		return
+	}
+	nodes.PrintTree(w, p.root, p.label)
+	// removed files are no longer part of the canvas tree, locked ones aren't downloaded
+	for _, planned := range p.Files {
+		switch planned.Action {
+		case PLAN_PRUNE, PLAN_REMOVED:
+			fmt.Fprintf(w, "  %s [%s, %s]\n", planned.Path, planned.Action, utils.FormatSize(planned.Size))
+		case PLAN_LOCKED:
+			unlocks := "unlock date unknown"
+			if planned.UnlockAt != nil {
+				unlocks = "unlocks " + utils.FormatEventDate(planned.UnlockAt.Local())
+			}
+			fmt.Fprintf(w, "  %s [%s, %s]\n", planned.Path, planned.Action, unlocks)
+		}
+	}
+	fmt.Fprintf(w, "  %d file(s), %s to download\n", len(p.Files), utils.FormatSize(p.DownloadSize))
+}
+
+// PrintPlans writes the plans of every course as a tree or as json, followed by the total download size.
+// nil plans (courses that failed to plan) are skipped.
+func PrintPlans(w io.Writer, plans []*CoursePlan, format string) error {
+	planned := make([]*CoursePlan, 0, len(plans))
+	var total int64
+	for _, plan := range plans {
+		if plan != nil {
+			planned = append(planned, plan)
+			total += plan.DownloadSize
+		}
+	}
+	plans = planned
+	switch format {
+	case PLAN_FORMAT_JSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(struct {
+			Courses      []*CoursePlan `json:"courses"`
+			DownloadSize int64         `json:"download_size"`
+		}{Courses: plans, DownloadSize: total})
+	case PLAN_FORMAT_TREE:
+		for _, plan := range plans {
+			plan.printTree(w)
+			fmt.Fprintln(w)
+		}
+		fmt.Fprintf(w, "Total: %s to download\n", utils.FormatSize(total))
+		return nil
+	}
+	return ValidatePlanFormat(format)
+}
+
+func ValidatePlanFormat(format string) error {
+	if format == PLAN_FORMAT_TREE || format == PLAN_FORMAT_JSON {
+		return nil
+	}
+	return fmt.Errorf("unknown plan format '%s', must be %s or %s", format, PLAN_FORMAT_TREE, PLAN_FORMAT_JSON)
+}
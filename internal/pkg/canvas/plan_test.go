@@ -0,0 +1,204 @@
+package canvas
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aidanaden/canvas-sync/internal/pkg/manifest"
+	"github.com/aidanaden/canvas-sync/internal/pkg/nodes"
+)
+
+var planUpdatedAt = time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+
+// planFixture returns a course's file tree with a new, a changed, a renamed, an unchanged and a locked file, and a
+// manifest that also holds a file deleted from canvas
+func planFixture(t *testing.T) (*nodes.DirectoryNode, *manifest.Manifest) {
+	t.Helper()
+	courseDir := t.TempDir()
+	filesDir := filepath.Join(courseDir, FILES_DIR)
+	unlockAt := time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC)
+	root := &nodes.DirectoryNode{
+		ID:       1,
+		FullName: "course files",
+		FileNodes: []*nodes.FileNode{
+			{ID: 10, FolderID: 1, Display_name: "new.pdf", Size: 100, UpdatedAt: planUpdatedAt},
+			{ID: 11, FolderID: 1, Display_name: "changed.pdf", Size: 200, UpdatedAt: planUpdatedAt.Add(time.Hour)},
+			{ID: 12, FolderID: 1, Display_name: "renamed.pdf", Size: 300, UpdatedAt: planUpdatedAt},
+			{ID: 13, FolderID: 1, Display_name: "unchanged.pdf", Size: 7, UpdatedAt: planUpdatedAt},
+			{ID: 14, FolderID: 1, Display_name: "answers.pdf", Size: 400, LockedForUser: true, UnlockAt: &unlockAt},
+		},
+	}
+	setTreeDirectories(root, filesDir)
+	m, err := manifest.Load(courseDir, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, file := range []struct {
+		name  string
+		entry manifest.Entry
+	}{
+		{"changed.pdf", manifest.Entry{FileID: 11, FolderID: 1, Size: 7, UpdatedAt: planUpdatedAt, CanvasPath: "changed.pdf"}},
+		{"old name.pdf", manifest.Entry{FileID: 12, FolderID: 1, Size: 7, UpdatedAt: planUpdatedAt, CanvasPath: "old name.pdf"}},
+		{"unchanged.pdf", manifest.Entry{FileID: 13, FolderID: 1, Size: 7, UpdatedAt: planUpdatedAt, CanvasPath: "unchanged.pdf"}},
+		{"deleted.pdf", manifest.Entry{FileID: 15, FolderID: 1, Size: 7, UpdatedAt: planUpdatedAt, CanvasPath: "deleted.pdf"}},
+	} {
+		localPath := filepath.Join(filesDir, file.name)
+		writeTestFile(t, localPath)
+		m.Put(localPath, file.entry)
+	}
+	return root, m
+}
+
+func writeTestFile(t *testing.T, path string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte("content"), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// planned returns the planned files by their base name
+func planned(plan *CoursePlan) map[string]PlannedFile {
+	byName := make(map[string]PlannedFile)
+	for _, file := range plan.Files {
+		byName[filepath.Base(file.Path)] = file
+	}
+	return byName
+}
+
+func TestPlanUpdate(t *testing.T) {
+	root, m := planFixture(t)
+	filesDir := root.Directory
+
+	plan, err := PlanUpdate("CS1010", root, m, UpdateOptions{Force: true}, false, FileFilter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := planned(plan)
+	want := map[string]PlanAction{
+		"new.pdf":     PLAN_CREATE,
+		"changed.pdf": PLAN_OVERWRITE,
+		"renamed.pdf": PLAN_RENAME,
+		"deleted.pdf": PLAN_REMOVED,
+		"answers.pdf": PLAN_LOCKED,
+	}
+	if len(got) != len(want) {
+		t.Errorf("planned %v, want %v", plan.Files, want)
+	}
+	for name, action := range want {
+		if got[name].Action != action {
+			t.Errorf("planned %s for %s, want %s", got[name].Action, name, action)
+		}
+	}
+	if from := got["renamed.pdf"].From; from != filepath.Join(filesDir, "old name.pdf") {
+		t.Errorf("planned the rename from %s, want old name.pdf", from)
+	}
+	if unlockAt := got["answers.pdf"].UnlockAt; unlockAt == nil {
+		t.Error("planned the locked file without its unlock date")
+	}
+	if plan.DownloadSize != 300 {
+		t.Errorf("planned %d bytes to download, want the new and changed files' 300", plan.DownloadSize)
+	}
+	// planning doesn't touch anything
+	for _, name := range []string{"old name.pdf", "deleted.pdf"} {
+		if _, err := os.Stat(filepath.Join(filesDir, name)); err != nil {
+			t.Errorf("%s was moved while planning", name)
+		}
+	}
+
+	plan, err = PlanUpdate("CS1010", root, m, UpdateOptions{}, true, FileFilter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got = planned(plan)
+	if got["deleted.pdf"].Action != PLAN_PRUNE {
+		t.Errorf("planned %s for the deleted file with prune, want %s", got["deleted.pdf"].Action, PLAN_PRUNE)
+	}
+	if _, ok := got["changed.pdf"]; ok {
+		t.Error("planned to overwrite a changed file without force")
+	}
+}
+
+func TestPlanCreate(t *testing.T) {
+	root, _ := planFixture(t)
+
+	got := planned(PlanCreate("CS1010", root))
+	want := map[string]PlanAction{
+		"new.pdf":       PLAN_CREATE,
+		"changed.pdf":   PLAN_OVERWRITE,
+		"renamed.pdf":   PLAN_CREATE,
+		"unchanged.pdf": PLAN_OVERWRITE,
+		"answers.pdf":   PLAN_LOCKED,
+	}
+	if len(got) != len(want) {
+		t.Errorf("planned %v, want %v", got, want)
+	}
+	for name, action := range want {
+		if got[name].Action != action {
+			t.Errorf("planned %s for %s, want %s", got[name].Action, name, action)
+		}
+	}
+}
+
+func TestPrintPlans(t *testing.T) {
+	root, m := planFixture(t)
+	plan, err := PlanUpdate("CS1010", root, m, UpdateOptions{Force: true}, true, FileFilter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	plans := []*CoursePlan{plan, nil}
+
+	var tree bytes.Buffer
+	if err := PrintPlans(&tree, plans, PLAN_FORMAT_TREE); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"CS1010:",
+		"new.pdf [create, ",
+		"changed.pdf [overwrite, ",
+		"renamed.pdf [rename from " + filepath.Join(root.Directory, "old name.pdf") + "]",
+		"deleted.pdf [prune, ",
+		"answers.pdf [locked, unlocks ",
+		"5 file(s)",
+		"Total: ",
+	} {
+		if !strings.Contains(tree.String(), want) {
+			t.Errorf("tree plan is missing %q:\n%s", want, tree.String())
+		}
+	}
+	if strings.Contains(tree.String(), "unchanged.pdf") {
+		t.Errorf("tree plan lists the unchanged file:\n%s", tree.String())
+	}
+
+	var raw bytes.Buffer
+	if err := PrintPlans(&raw, plans, PLAN_FORMAT_JSON); err != nil {
+		t.Fatal(err)
+	}
+	var decoded struct {
+		Courses []struct {
+			Course string        `json:"course"`
+			Files  []PlannedFile `json:"files"`
+		} `json:"courses"`
+		DownloadSize int64 `json:"download_size"`
+	}
+	if err := json.Unmarshal(raw.Bytes(), &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if len(decoded.Courses) != 1 || decoded.Courses[0].Course != "CS1010" || len(decoded.Courses[0].Files) != 5 {
+		t.Errorf("json plan is %+v, want the 5 planned files of CS1010", decoded)
+	}
+	if decoded.DownloadSize != 300 {
+		t.Errorf("json plan downloads %d bytes, want 300", decoded.DownloadSize)
+	}
+
+	if err := PrintPlans(&raw, plans, "yaml"); err == nil {
+		t.Error("printed a plan in an unknown format")
+	}
+}
@@ -0,0 +1,74 @@
+package canvas
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/aidanaden/canvas-sync/internal/pkg/manifest"
+	"github.com/aidanaden/canvas-sync/internal/pkg/nodes"
+	"github.com/aidanaden/canvas-sync/internal/pkg/trash"
+)
+
+// planRemoved returns the local paths of downloaded files that no longer exist on canvas,
+// and of manifest entries whose local copy is gone as well. Files skipped by filter are never considered removed,
+// matched on their path on canvas like the tree is filtered.
+func planRemoved(root *nodes.DirectoryNode, m *manifest.Manifest, filter FileFilter) ([]string, []string) {
+	remote := make(map[int]bool)
+	for _, file := range collectFileNodes(root, nil) {
+		remote[file.ID] = true
+	}
+	// locked folders may not have been listed, their files aren't known to be removed
+	locked := lockedDirs(root, nil)
+	removed := make([]string, 0)
+	untracked := make([]string, 0)
+	for _, entry := range m.Entries(root.Directory) {
+		if remote[entry.FileID] {
+			continue
+		}
+		localPath := m.LocalPath(&entry)
+		if insideAny(locked, localPath) {
+			continue
+		}
+		canvasPath := entry.CanvasPath
+		if canvasPath == "" {
+			// downloaded before canvas paths were recorded, when local paths weren't sanitised
+			if rel, err := filepath.Rel(root.Directory, localPath); err == nil {
+				canvasPath = filepath.ToSlash(rel)
+			}
+		}
+		if canvasPath != "" && filter.SkipsFile(canvasPath, "", entry.Size) {
+			continue
+		}
+		if _, err := os.Stat(localPath); os.IsNotExist(err) {
+			untracked = append(untracked, localPath)
+			continue
+		}
+		removed = append(removed, localPath)
+	}
+	return removed, untracked
+}
+
+// PruneRemoved finds downloaded files that no longer exist on canvas, moving them into the
+// course's trash if prune is set, otherwise only reporting them
+func PruneRemoved(root *nodes.DirectoryNode, m *manifest.Manifest, courseDir string, prune bool, filter FileFilter, report *SyncReport) {
+	removed, untracked := planRemoved(root, m, filter)
+	// deleted locally as well, nothing left to track
+	for _, localPath := range untracked {
+		m.Remove(localPath)
+	}
+	now := time.Now()
+	for _, localPath := range removed {
+		if !prune {
+			report.AddRemoved(localPath, "")
+			continue
+		}
+		trashed, err := trash.Move(courseDir, localPath, now)
+		if err != nil {
+			report.AddFailed(localPath, err)
+			continue
+		}
+		m.Remove(localPath)
+		report.AddRemoved(localPath, trashed)
+	}
+}
@@ -0,0 +1,51 @@
+package canvas
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/aidanaden/canvas-sync/internal/pkg/manifest"
+	"github.com/aidanaden/canvas-sync/internal/pkg/nodes"
+)
+
+func TestPlanRemoved(t *testing.T) {
+	courseDir := t.TempDir()
+	root := &nodes.DirectoryNode{
+		Directory: filepath.Join(courseDir, FILES_DIR),
+		FileNodes: []*nodes.FileNode{{ID: 1}},
+	}
+	m, err := manifest.Load(courseDir, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	put := func(rel string, entry manifest.Entry, onDisk bool) string {
+		localPath := filepath.Join(root.Directory, filepath.FromSlash(rel))
+		m.Put(localPath, entry)
+		if onDisk {
+			if err := os.MkdirAll(filepath.Dir(localPath), 0755); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(localPath, nil, 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return localPath
+	}
+	put("kept.pdf", manifest.Entry{FileID: 1, CanvasPath: "kept.pdf"}, true)
+	deleted := put("deleted.pdf", manifest.Entry{FileID: 2, CanvasPath: "deleted.pdf"}, true)
+	// the sanitised local folder doesn't match the filter, its path on canvas does
+	put("Week 1- Intro/notes.pdf", manifest.Entry{FileID: 3, CanvasPath: "Week 1: Intro/notes.pdf"}, true)
+	// entries from before canvas paths were recorded fall back to the local path
+	put("Old/slides.pdf", manifest.Entry{FileID: 4}, true)
+	gone := put("gone.pdf", manifest.Entry{FileID: 5, CanvasPath: "gone.pdf"}, false)
+
+	filter := FileFilter{Exclude: []string{"Week 1: Intro", "Old"}}
+	removed, untracked := planRemoved(root, m, filter)
+	if len(removed) != 1 || removed[0] != deleted {
+		t.Errorf("removed %v, want only %s", removed, deleted)
+	}
+	if len(untracked) != 1 || untracked[0] != gone {
+		t.Errorf("untracked %v, want only %s", untracked, gone)
+	}
+}
@@ -0,0 +1,127 @@
+package canvas
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// start slowing down requests once canvas's rate limit bucket drops below this
+	RATE_LIMIT_THRESHOLD = 300.0
+	// longest delay added to a request while the bucket is draining
+	MAX_THROTTLE_DELAY = 5 * time.Second
+	// pause all requests for this long once canvas rejects one for exceeding the rate limit
+	RATE_LIMIT_PAUSE = 30 * time.Second
+)
+
+// rateLimitTransport limits the number of requests waiting on a response and slows down
+// or pauses requests based on canvas's X-Rate-Limit-Remaining/X-Request-Cost headers.
+// A request's slot is freed once its headers arrive, so reading a large download's body never holds up api calls:
+// how many files download at once is up to the scheduler's workers.
+type rateLimitTransport struct {
+	base        http.RoundTripper
+	slots       chan struct{}
+	mu          sync.Mutex
+	remaining   float64
+	lastCost    float64
+	pausedUntil time.Time
+}
+
+func newRateLimitTransport(base http.RoundTripper, maxConcurrency int) *rateLimitTransport {
+	t := &rateLimitTransport{
+		base:      base,
+		remaining: -1,
+	}
+	if maxConcurrency > 0 {
+		t.slots = make(chan struct{}, maxConcurrency)
+	}
+	return t
+}
+
+// delay returns how long the next request should wait before being sent
+func (t *rateLimitTransport) delay() time.Duration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if wait := time.Until(t.pausedUntil); wait > 0 {
+		return wait
+	}
+	// no rate limit info received yet
+	if t.remaining < 0 || t.remaining >= RATE_LIMIT_THRESHOLD {
+		return 0
+	}
+	if t.remaining <= t.lastCost {
+		return RATE_LIMIT_PAUSE
+	}
+	drained := (RATE_LIMIT_THRESHOLD - t.remaining) / RATE_LIMIT_THRESHOLD
+	return time.Duration(drained * float64(MAX_THROTTLE_DELAY))
+}
+
+func (t *rateLimitTransport) record(resp *http.Response) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if remaining, err := strconv.ParseFloat(resp.Header.Get("X-Rate-Limit-Remaining"), 64); err == nil {
+		t.remaining = remaining
+	}
+	if cost, err := strconv.ParseFloat(resp.Header.Get("X-Request-Cost"), 64); err == nil {
+		t.lastCost = cost
+	}
+	if isRateLimitedResponse(resp) {
+		t.pausedUntil = time.Now().Add(RATE_LIMIT_PAUSE)
+	}
+}
+
+// isRateLimitedResponse checks for canvas's "403 Forbidden (Rate Limit Exceeded)", buffering the body so it can still be read
+func isRateLimitedResponse(resp *http.Response) bool {
+	if resp.StatusCode == http.StatusTooManyRequests {
+		return true
+	}
+	if resp.StatusCode != http.StatusForbidden {
+		return false
+	}
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	if err != nil {
+		return false
+	}
+	return strings.Contains(strings.ToLower(string(body)), "rate limit exceeded")
+}
+
+func (t *rateLimitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.slots != nil {
+		select {
+		case t.slots <- struct{}{}:
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		}
+	}
+	release := func() {
+		if t.slots != nil {
+			<-t.slots
+		}
+	}
+
+	if wait := t.delay(); wait > 0 {
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-req.Context().Done():
+			timer.Stop()
+			release()
+			return nil, req.Context().Err()
+		}
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	release()
+	if err != nil {
+		return nil, err
+	}
+	t.record(resp)
+	return resp, nil
+}
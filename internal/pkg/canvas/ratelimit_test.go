@@ -0,0 +1,70 @@
+package canvas
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestRateLimitTransportFreesSlotOnHeaders(t *testing.T) {
+	transport := newRateLimitTransport(roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: http.StatusOK, Header: http.Header{}, Body: io.NopCloser(strings.NewReader("body"))}, nil
+	}), 1)
+	req, _ := http.NewRequest("GET", "https://canvas.example.com/api/v1/courses", nil)
+
+	// a download whose body is still being read
+	download, err := transport.RoundTrip(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer download.Body.Close()
+
+	done := make(chan error, 1)
+	go func() {
+		resp, err := transport.RoundTrip(req)
+		if err == nil {
+			resp.Body.Close()
+		}
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("request blocked until an earlier response's body was closed")
+	}
+}
+
+func TestRateLimitTransportDelay(t *testing.T) {
+	tests := []struct {
+		name      string
+		remaining float64
+		cost      float64
+		want      time.Duration
+	}{
+		{"no rate limit info", -1, 0, 0},
+		{"above threshold", RATE_LIMIT_THRESHOLD, 1, 0},
+		{"half drained", RATE_LIMIT_THRESHOLD / 2, 1, MAX_THROTTLE_DELAY / 2},
+		{"bucket empty", 1, 2, RATE_LIMIT_PAUSE},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			transport := newRateLimitTransport(http.DefaultTransport, 0)
+			transport.remaining = tt.remaining
+			transport.lastCost = tt.cost
+			if got := transport.delay(); got != tt.want {
+				t.Errorf("delay() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,211 @@
+package canvas
+
+import (
+	"sync"
+	"time"
+
+	"github.com/aidanaden/canvas-sync/internal/pkg/utils"
+	"github.com/pterm/pterm"
+)
+
+type FailedDownload struct {
+	Path string
+	Err  error
+}
+
+// MovedFile is a local file renamed or moved to mirror canvas
+type MovedFile struct {
+	From string
+	To   string
+	// moved into another folder rather than renamed in place
+	FolderChanged bool
+}
+
+// RemovedFile is a downloaded file that no longer exists on canvas
+type RemovedFile struct {
+	Path string
+	// location in the course's trash, empty if it was left in place
+	TrashedTo string
+}
+
+// LockedItem is a file or folder skipped because it's locked or hidden from the user
+type LockedItem struct {
+	Path string
+	// nil if canvas doesn't say when it unlocks
+	UnlockAt *time.Time
+}
+
+// MissingItem is something a course links to (e.g. a module item) that no longer exists on canvas
+type MissingItem struct {
+	Path string
+	Err  error
+}
+
+// SyncReport collects the outcome of a file sync across all courses, safe for concurrent use
+type SyncReport struct {
+	mu         sync.Mutex
+	Downloaded []string
+	Failed     []FailedDownload
+	Moved      []MovedFile
+	Removed    []RemovedFile
+	Locked     []LockedItem
+	Missing    []MissingItem
+}
+
+func (r *SyncReport) AddDownloaded(path string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.Downloaded = append(r.Downloaded, path)
+}
+
+func (r *SyncReport) AddFailed(path string, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.Failed = append(r.Failed, FailedDownload{Path: path, Err: err})
+}
+
+func (r *SyncReport) AddMoved(from string, to string, folderChanged bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.Moved = append(r.Moved, MovedFile{From: from, To: to, FolderChanged: folderChanged})
+}
+
+func (r *SyncReport) AddRemoved(path string, trashedTo string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.Removed = append(r.Removed, RemovedFile{Path: path, TrashedTo: trashedTo})
+}
+
+func (r *SyncReport) AddLocked(path string, unlockAt *time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.Locked = append(r.Locked, LockedItem{Path: path, UnlockAt: unlockAt})
+}
+
+// AddMissing records an item skipped because what it links to was deleted from canvas
+func (r *SyncReport) AddMissing(path string, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.Missing = append(r.Missing, MissingItem{Path: path, Err: err})
+}
+
+// PrintInterrupted summarises what finished before a sync was cancelled
+func (r *SyncReport) PrintInterrupted() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	pterm.Warning.Printfln("Interrupted, %d file(s) finished downloading before stopping, partial downloads resume on the next run", len(r.Downloaded))
+	pterm.Println()
+}
+
+// Print renders everything that needs the user's attention after a sync
+func (r *SyncReport) Print() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.printMoved()
+	r.printRemoved()
+	r.printLocked()
+	r.printMissing()
+	r.printFailed()
+}
+
+func (r *SyncReport) printMissing() {
+	if len(r.Missing) == 0 {
+		return
+	}
+	tableData := pterm.TableData{
+		{"File", "Error"},
+	}
+	for _, missing := range r.Missing {
+		tableData = append(tableData, []string{missing.Path, ErrorWithHint(missing.Err)})
+	}
+	pterm.Info.Printfln("Skipped %d item(s) linking to files that no longer exist on canvas:", len(r.Missing))
+	if err := pterm.DefaultTable.WithHasHeader().WithData(tableData).Render(); err != nil {
+		pterm.Error.Printfln("Error rendering missing files: %s", err.Error())
+	}
+	pterm.Println()
+}
+
+func (r *SyncReport) printLocked() {
+	if len(r.Locked) == 0 {
+		return
+	}
+	tableData := pterm.TableData{
+		{"File", "Unlocks"},
+	}
+	for _, locked := range r.Locked {
+		unlocks := "unknown"
+		if locked.UnlockAt != nil {
+			unlocks = utils.FormatEventDate(locked.UnlockAt.Local())
+		}
+		tableData = append(tableData, []string{locked.Path, unlocks})
+	}
+	pterm.Info.Printfln("Skipped %d locked or hidden file(s)/folder(s), they'll be downloaded by 'update files' once unlocked:", len(r.Locked))
+	if err := pterm.DefaultTable.WithHasHeader().WithData(tableData).Render(); err != nil {
+		pterm.Error.Printfln("Error rendering locked files: %s", err.Error())
+	}
+	pterm.Println()
+}
+
+func (r *SyncReport) printRemoved() {
+	if len(r.Removed) == 0 {
+		return
+	}
+	tableData := pterm.TableData{
+		{"File", "Moved to"},
+	}
+	kept := 0
+	for _, removed := range r.Removed {
+		trashedTo := removed.TrashedTo
+		if trashedTo == "" {
+			trashedTo = "-"
+			kept++
+		}
+		tableData = append(tableData, []string{removed.Path, trashedTo})
+	}
+	pterm.Info.Printfln("%d downloaded file(s) no longer exist on canvas:", len(r.Removed))
+	if err := pterm.DefaultTable.WithHasHeader().WithData(tableData).Render(); err != nil {
+		pterm.Error.Printfln("Error rendering removed files: %s", err.Error())
+	}
+	if kept > 0 {
+		pterm.Info.Printfln("Run with --prune to move them into the course's trash (see 'canvas-sync trash -h')")
+	}
+	pterm.Println()
+}
+
+func (r *SyncReport) printMoved() {
+	if len(r.Moved) == 0 {
+		return
+	}
+	tableData := pterm.TableData{
+		{"From", "To", "Change"},
+	}
+	for _, moved := range r.Moved {
+		change := "renamed"
+		if moved.FolderChanged {
+			change = "moved"
+		}
+		tableData = append(tableData, []string{moved.From, moved.To, change})
+	}
+	pterm.Info.Printfln("Renamed or moved %d file(s) to match canvas:", len(r.Moved))
+	if err := pterm.DefaultTable.WithHasHeader().WithData(tableData).Render(); err != nil {
+		pterm.Error.Printfln("Error rendering moved files: %s", err.Error())
+	}
+	pterm.Println()
+}
+
+func (r *SyncReport) printFailed() {
+	if len(r.Failed) == 0 {
+		return
+	}
+	tableData := pterm.TableData{
+		{"File", "Error"},
+	}
+	for _, failed := range r.Failed {
+		tableData = append(tableData, []string{failed.Path, ErrorWithHint(failed.Err)})
+	}
+	pterm.Warning.Printfln("Failed to download %d file(s):", len(r.Failed))
+	if err := pterm.DefaultTable.WithHasHeader().WithData(tableData).Render(); err != nil {
+		pterm.Error.Printfln("Error rendering failed downloads: %s", err.Error())
+	}
+	pterm.Println()
+}
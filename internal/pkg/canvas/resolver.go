@@ -0,0 +1,177 @@
+package canvas
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/aidanaden/canvas-sync/internal/pkg/nodes"
+	"github.com/lithammer/fuzzysearch/fuzzy"
+)
+
+// max edit distance for a course identifier to be suggested on a typo
+const MAX_SUGGESTION_DISTANCE = 2
+
+var ErrAmbiguousCourse = errors.New("course matches multiple enrolled courses")
+
+// CourseNotFoundError is returned when no enrolled course matches, with the closest matches as suggestions
+type CourseNotFoundError struct {
+	Query       string
+	Suggestions []nodes.CourseNode
+}
+
+func (e *CourseNotFoundError) Error() string {
+	if len(e.Suggestions) == 0 {
+		return fmt.Sprintf("no enrolled course matches '%s'", e.Query)
+	}
+	suggestions := make([]string, 0, len(e.Suggestions))
+	for _, course := range e.Suggestions {
+		suggestions = append(suggestions, describeCourse(course))
+	}
+	return fmt.Sprintf("no enrolled course matches '%s', did you mean: %s", e.Query, strings.Join(suggestions, ", "))
+}
+
+func (e *CourseNotFoundError) Is(target error) bool {
+	return target == ErrNotFound
+}
+
+// AmbiguousCourseError is returned when an identifier matches more than one enrolled course
+type AmbiguousCourseError struct {
+	Query   string
+	Matches []nodes.CourseNode
+}
+
+func (e *AmbiguousCourseError) Error() string {
+	matches := make([]string, 0, len(e.Matches))
+	for _, course := range e.Matches {
+		matches = append(matches, describeCourse(course))
+	}
+	return fmt.Sprintf("'%s' matches multiple courses: %s", e.Query, strings.Join(matches, ", "))
+}
+
+func (e *AmbiguousCourseError) Is(target error) bool {
+	return target == ErrAmbiguousCourse
+}
+
+func describeCourse(course nodes.CourseNode) string {
+	return fmt.Sprintf("%s (id %d, %s)", course.CourseCode, course.ID, course.Name)
+}
+
+// courseIdentifiers returns every lower-cased identifier a course can be referred to by:
+// its code (as displayed and as stored), each code of a cross-listed course, its name and its nickname
+func courseIdentifiers(course nodes.CourseNode) []string {
+	identifiers := []string{course.CourseCode, course.OriginalCourseCode, course.Name, course.OriginalName}
+	// cross-listed courses e.g. "CS2030/CS2030S"
+	if codes := strings.Split(course.OriginalCourseCode, "/"); len(codes) > 1 {
+		identifiers = append(identifiers, codes...)
+	}
+	lowered := make([]string, 0, len(identifiers))
+	for _, identifier := range identifiers {
+		identifier = strings.ToLower(strings.TrimSpace(identifier))
+		if identifier != "" {
+			lowered = append(lowered, identifier)
+		}
+	}
+	return lowered
+}
+
+// ResolveCourse finds the enrolled course referred to by a course id, code, full name or nickname (case-insensitive)
+func (c *CanvasClient) ResolveCourse(ctx context.Context, query string) (nodes.CourseNode, error) {
+	courses, err := c.GetEnrolledCourses(ctx)
+	if err != nil {
+		return nodes.CourseNode{}, err
+	}
+	query = strings.ToLower(strings.TrimSpace(query))
+
+	if id, err := strconv.Atoi(query); err == nil {
+		for _, course := range courses {
+			if course.ID == id {
+				return course, nil
+			}
+		}
+	}
+
+	matches := make([]nodes.CourseNode, 0)
+	for _, course := range courses {
+		for _, identifier := range courseIdentifiers(course) {
+			if identifier == query {
+				matches = append(matches, course)
+				break
+			}
+		}
+	}
+	if len(matches) == 1 {
+		return matches[0], nil
+	}
+	if len(matches) > 1 {
+		return nodes.CourseNode{}, &AmbiguousCourseError{Query: query, Matches: matches}
+	}
+	return nodes.CourseNode{}, &CourseNotFoundError{Query: query, Suggestions: suggestCourses(query, courses)}
+}
+
+// suggestCourses returns courses with an identifier within a few typos of query, closest first
+func suggestCourses(query string, courses []nodes.CourseNode) []nodes.CourseNode {
+	type suggestion struct {
+		course   nodes.CourseNode
+		distance int
+	}
+	suggestions := make([]suggestion, 0)
+	for _, course := range courses {
+		best := -1
+		for _, identifier := range courseIdentifiers(course) {
+			distance := fuzzy.LevenshteinDistance(query, identifier)
+			// partial names e.g. "algorithms" for "Design and Analysis of Algorithms"
+			if fuzzy.Match(query, identifier) {
+				distance = 0
+			}
+			if best == -1 || distance < best {
+				best = distance
+			}
+		}
+		if best != -1 && best <= MAX_SUGGESTION_DISTANCE {
+			suggestions = append(suggestions, suggestion{course: course, distance: best})
+		}
+	}
+	sort.SliceStable(suggestions, func(i, j int) bool {
+		return suggestions[i].distance < suggestions[j].distance
+	})
+	suggested := make([]nodes.CourseNode, 0, len(suggestions))
+	for _, s := range suggestions {
+		suggested = append(suggested, s.course)
+	}
+	return suggested
+}
+
+// ResolveCourses resolves each query to an enrolled course, returning all courses with a course code if none are given
+func (c *CanvasClient) ResolveCourses(ctx context.Context, queries []string) ([]nodes.CourseNode, error) {
+	if len(queries) == 0 {
+		courses, err := c.GetEnrolledCourses(ctx)
+		if err != nil {
+			return nil, err
+		}
+		withCode := make([]nodes.CourseNode, 0, len(courses))
+		for _, course := range courses {
+			if course.CourseCode != "" {
+				withCode = append(withCode, course)
+			}
+		}
+		return withCode, nil
+	}
+	courses := make([]nodes.CourseNode, 0, len(queries))
+	seen := make(map[int]bool)
+	for _, query := range queries {
+		course, err := c.ResolveCourse(ctx, query)
+		if err != nil {
+			return nil, err
+		}
+		if seen[course.ID] {
+			continue
+		}
+		seen[course.ID] = true
+		courses = append(courses, course)
+	}
+	return courses, nil
+}
@@ -0,0 +1,117 @@
+package canvas
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func resolverClient(t *testing.T) *CanvasClient {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v1/users/self/courses", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[
+			{"id": 101, "course_code": "CS2030/CS2030S", "name": "Programming Methodology II"},
+			{"id": 102, "course_code": "CS3230", "name": "Algos", "original_name": "Design and Analysis of Algorithms"},
+			{"id": 103, "course_code": "MA1521", "name": "Calculus for Computing"},
+			{"id": 104, "course_code": "CS2030S", "name": "Programming Methodology II (Lab)"},
+			{"id": 105, "course_code": "", "name": "Orientation"}
+		]`)
+	})
+	return newAPIClient(t, mux)
+}
+
+func TestResolveCourse(t *testing.T) {
+	c := resolverClient(t)
+	tests := []struct {
+		name  string
+		query string
+		want  int
+	}{
+		{"id", "103", 103},
+		{"code", "ma1521", 103},
+		{"cross-listed code", "CS2030", 101},
+		{"full code of a cross-listed course", "cs2030/cs2030s", 101},
+		{"nickname", "algos", 102},
+		{"name behind a nickname", "Design and Analysis of Algorithms", 102},
+		{"surrounding spaces", "  CS3230 ", 102},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			course, err := c.ResolveCourse(context.Background(), tt.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if course.ID != tt.want {
+				t.Errorf("ResolveCourse(%q) = %d, want %d", tt.query, course.ID, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolveCourseAmbiguous(t *testing.T) {
+	c := resolverClient(t)
+	_, err := c.ResolveCourse(context.Background(), "cs2030s")
+	var ambiguous *AmbiguousCourseError
+	if !errors.As(err, &ambiguous) || !errors.Is(err, ErrAmbiguousCourse) {
+		t.Fatalf("ResolveCourse() error = %v, want an ambiguous course", err)
+	}
+	if len(ambiguous.Matches) != 2 || ambiguous.Matches[0].ID != 101 || ambiguous.Matches[1].ID != 104 {
+		t.Errorf("matched %v, want courses 101 and 104", ambiguous.Matches)
+	}
+}
+
+func TestResolveCourseNotFound(t *testing.T) {
+	c := resolverClient(t)
+	tests := []struct {
+		name  string
+		query string
+		want  []int
+	}{
+		{"typo", "ma1512", []int{103}},
+		{"partial name", "algorithms", []int{102}},
+		{"unknown id", "999", nil},
+		{"nothing close", "geography", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := c.ResolveCourse(context.Background(), tt.query)
+			var notFound *CourseNotFoundError
+			if !errors.As(err, &notFound) || !errors.Is(err, ErrNotFound) {
+				t.Fatalf("ResolveCourse(%q) error = %v, want course not found", tt.query, err)
+			}
+			got := make([]int, 0)
+			for _, course := range notFound.Suggestions {
+				got = append(got, course.ID)
+			}
+			if len(got) != len(tt.want) || (len(got) > 0 && got[0] != tt.want[0]) {
+				t.Errorf("suggested %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolveCourses(t *testing.T) {
+	c := resolverClient(t)
+
+	all, err := c.ResolveCourses(context.Background(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != 4 {
+		t.Errorf("resolved %d courses without queries, want the 4 with a course code", len(all))
+	}
+
+	courses, err := c.ResolveCourses(context.Background(), []string{"CS3230", "algos", "103"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(courses) != 2 || courses[0].ID != 102 || courses[1].ID != 103 {
+		t.Errorf("resolved %v, want courses 102 and 103 once each", courses)
+	}
+
+	if _, err := c.ResolveCourses(context.Background(), []string{"CS3230", "geography"}); !errors.Is(err, ErrNotFound) {
+		t.Errorf("ResolveCourses() error = %v, want course not found", err)
+	}
+}
@@ -0,0 +1,74 @@
+package canvas
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand"
+	"net"
+	"slices"
+	"time"
+)
+
+type RetryPolicy struct {
+	// total attempts including the first, values below 1 are treated as 1
+	MaxAttempts int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+	// fraction (0-1) of each backoff delay that is randomised
+	Jitter float64
+	// http status codes that are retried, network errors and rate limiting are always retried
+	RetryStatuses []int
+}
+
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts:   5,
+	BaseDelay:     time.Second,
+	MaxDelay:      30 * time.Second,
+	Jitter:        0.5,
+	RetryStatuses: []int{408, 429, 500, 502, 503, 504},
+}
+
+func (p RetryPolicy) shouldRetry(err error) bool {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return errors.Is(err, ErrRateLimited) || slices.Contains(p.RetryStatuses, apiErr.StatusCode)
+	}
+	// connection failures, timeouts and truncated bodies
+	var netErr net.Error
+	return errors.As(err, &netErr) || errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+// backoff returns the exponential delay before the given retry attempt (starting from 1)
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	delay := p.MaxDelay
+	// compare before shifting, a large attempt would overflow the delay
+	if shift := attempt - 1; shift < 63 && p.BaseDelay > 0 && p.BaseDelay <= p.MaxDelay>>shift {
+		delay = p.BaseDelay << shift
+	}
+	if p.Jitter > 0 {
+		jitter := time.Duration(min(p.Jitter, 1) * float64(delay))
+		delay = delay - jitter + time.Duration(rand.Int63n(int64(2*jitter)+1))
+	}
+	return delay
+}
+
+// withRetry runs fn until it succeeds, returns an error that should not be retried, runs out of attempts or ctx is cancelled
+func (c *CanvasClient) withRetry(ctx context.Context, fn func() error) error {
+	var err error
+	for attempt := 1; ; attempt++ {
+		if err = fn(); err == nil {
+			return nil
+		}
+		if ctx.Err() != nil || attempt >= c.retryPolicy.MaxAttempts || !c.retryPolicy.shouldRetry(err) {
+			return err
+		}
+		timer := time.NewTimer(c.retryPolicy.backoff(attempt))
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		}
+	}
+}
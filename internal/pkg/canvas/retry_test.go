@@ -0,0 +1,167 @@
+package canvas
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestBackoff(t *testing.T) {
+	policy := RetryPolicy{BaseDelay: time.Second, MaxDelay: 30 * time.Second}
+	tests := []struct {
+		attempt int
+		want    time.Duration
+	}{
+		{1, time.Second},
+		{2, 2 * time.Second},
+		{5, 16 * time.Second},
+		{6, 30 * time.Second},
+		{40, 30 * time.Second},
+		{100, 30 * time.Second},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprint(tt.attempt), func(t *testing.T) {
+			if got := policy.backoff(tt.attempt); got != tt.want {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBackoffJitter(t *testing.T) {
+	tests := []struct {
+		name     string
+		jitter   float64
+		min, max time.Duration
+	}{
+		{"half", 0.5, 2 * time.Second, 6 * time.Second},
+		{"full", 1, 0, 8 * time.Second},
+		{"above one is full", 3, 0, 8 * time.Second},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy := RetryPolicy{BaseDelay: time.Second, MaxDelay: time.Minute, Jitter: tt.jitter}
+			for i := 0; i < 1000; i++ {
+				if got := policy.backoff(3); got < tt.min || got > tt.max {
+					t.Fatalf("got %s, want between %s and %s", got, tt.min, tt.max)
+				}
+			}
+		})
+	}
+}
+
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+
+var _ net.Error = timeoutError{}
+
+func TestShouldRetry(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"server error", &APIError{StatusCode: 503}, true},
+		{"too many requests", &APIError{StatusCode: 429}, true},
+		{"rate limited forbidden", &APIError{StatusCode: 403, Errors: []APIErrorMessage{{Message: "403 Forbidden (Rate Limit Exceeded)"}}}, true},
+		{"forbidden", &APIError{StatusCode: 403}, false},
+		{"not found", &APIError{StatusCode: 404}, false},
+		{"wrapped api error", fmt.Errorf("listing: %w", &APIError{StatusCode: 502}), true},
+		{"network error", &net.OpError{Op: "dial", Err: timeoutError{}}, true},
+		{"truncated body", fmt.Errorf("reading: %w", io.ErrUnexpectedEOF), true},
+		{"other error", errors.New("invalid json"), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DefaultRetryPolicy.shouldRetry(tt.err); got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWithRetry(t *testing.T) {
+	serverErr := &APIError{StatusCode: 500}
+	tests := []struct {
+		name      string
+		errs      []error
+		wantCalls int
+		wantErr   error
+	}{
+		{"succeeds first time", nil, 1, nil},
+		{"retries until success", []error{serverErr, serverErr}, 3, nil},
+		{"gives up after max attempts", []error{serverErr, serverErr, serverErr, serverErr}, 3, serverErr},
+		{"permanent error", []error{&APIError{StatusCode: 404}}, 1, ErrNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewClient("canvas.example.com", "token", WithRetryPolicy(RetryPolicy{
+				MaxAttempts:   3,
+				BaseDelay:     time.Millisecond,
+				MaxDelay:      time.Millisecond,
+				RetryStatuses: []int{500},
+			}))
+			defer c.Close()
+			calls := 0
+			err := c.withRetry(context.Background(), func() error {
+				calls++
+				if calls <= len(tt.errs) {
+					return tt.errs[calls-1]
+				}
+				return nil
+			})
+			if calls != tt.wantCalls {
+				t.Errorf("called %d times, want %d", calls, tt.wantCalls)
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("got error %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestWithRetryCancelled(t *testing.T) {
+	c := NewClient("canvas.example.com", "token", WithRetryPolicy(RetryPolicy{
+		MaxAttempts:   5,
+		BaseDelay:     time.Hour,
+		MaxDelay:      time.Hour,
+		RetryStatuses: []int{500},
+	}))
+	defer c.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+	calls := 0
+	called := make(chan struct{})
+	done := make(chan error)
+	go func() {
+		done <- c.withRetry(ctx, func() error {
+			calls++
+			if calls == 1 {
+				close(called)
+			}
+			return &APIError{StatusCode: 500}
+		})
+	}()
+	// cancel while waiting to retry
+	<-called
+	cancel()
+	select {
+	case err := <-done:
+		// either the cancellation or, if it landed before the backoff started, the failed attempt's error
+		var apiErr *APIError
+		if !errors.Is(err, context.Canceled) && !errors.As(err, &apiErr) {
+			t.Errorf("got error %v, want %v", err, context.Canceled)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("kept waiting to retry after being cancelled")
+	}
+	if calls != 1 {
+		t.Errorf("called %d times, want 1", calls)
+	}
+}
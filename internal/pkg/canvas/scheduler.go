@@ -0,0 +1,198 @@
+package canvas
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/aidanaden/canvas-sync/internal/pkg/manifest"
+	"github.com/aidanaden/canvas-sync/internal/pkg/nodes"
+)
+
+// Priority decides which of a course's queued files are downloaded first
+type Priority string
+
+const (
+	// canvas listing order
+	PRIORITY_DEFAULT      Priority = "default"
+	PRIORITY_SMALL_FIRST  Priority = "small"
+	PRIORITY_NEWEST_FIRST Priority = "newest"
+)
+
+const DEFAULT_WORKERS = 8
+
+var PRIORITIES = []Priority{PRIORITY_DEFAULT, PRIORITY_SMALL_FIRST, PRIORITY_NEWEST_FIRST}
+
+func ParsePriority(raw string) (Priority, error) {
+	if raw == "" {
+		return PRIORITY_DEFAULT, nil
+	}
+	for _, priority := range PRIORITIES {
+		if string(priority) == raw {
+			return priority, nil
+		}
+	}
+	return "", fmt.Errorf("unknown priority '%s', must be one of %v", raw, PRIORITIES)
+}
+
+func (p Priority) less(a *nodes.FileNode, b *nodes.FileNode) bool {
+	switch p {
+	case PRIORITY_SMALL_FIRST:
+		return a.Size < b.Size
+	case PRIORITY_NEWEST_FIRST:
+		return a.UpdatedAt.After(b.UpdatedAt)
+	}
+	return false
+}
+
+type downloadJob struct {
+	ctx    context.Context
+	node   *nodes.FileNode
+	m      *manifest.Manifest
+	report *SyncReport
+	wg     *sync.WaitGroup
+}
+
+// scheduler downloads files on a fixed pool of workers shared by every course,
+// taking turns between courses so one large course can't starve the rest. The workers are the only limit on
+// concurrent downloads, the client's max concurrency only counts requests until their headers arrive.
+type scheduler struct {
+	mu       sync.Mutex
+	cond     *sync.Cond
+	workers  int
+	priority Priority
+	started  bool
+	closed   bool
+	// queued jobs by course id
+	queues map[int][]*downloadJob
+	// course ids with queued jobs, in round robin order
+	ring []int
+}
+
+func newScheduler(workers int, priority Priority) *scheduler {
+	if workers <= 0 {
+		workers = DEFAULT_WORKERS
+	}
+	s := &scheduler{
+		workers:  workers,
+		priority: priority,
+		queues:   make(map[int][]*downloadJob),
+	}
+	s.cond = sync.NewCond(&s.mu)
+	return s
+}
+
+// submit queues jobs for a course, starting the workers on first use. Jobs still queued when ctx is cancelled are
+// dropped right away, call the returned stop once they're done to stop watching ctx.
+func (s *scheduler) submit(c *CanvasClient, ctx context.Context, courseId int, jobs []*downloadJob) (stop func() bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		for _, job := range jobs {
+			job.wg.Done()
+		}
+		return func() bool { return false }
+	}
+	if !s.started {
+		s.started = true
+		for i := 0; i < s.workers; i++ {
+			go s.work(c)
+		}
+	}
+	queue, queued := s.queues[courseId]
+	queue = append(queue, jobs...)
+	sort.SliceStable(queue, func(i, j int) bool {
+		return s.priority.less(queue[i].node, queue[j].node)
+	})
+	s.queues[courseId] = queue
+	if !queued {
+		s.ring = append(s.ring, courseId)
+	}
+	s.cond.Broadcast()
+	return context.AfterFunc(ctx, func() {
+		s.drop(func(job *downloadJob) bool { return job.ctx == ctx })
+	})
+}
+
+// drop removes the queued jobs matching drop, marking them done without running them
+func (s *scheduler) drop(drop func(job *downloadJob) bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.dropLocked(drop)
+}
+
+func (s *scheduler) dropLocked(drop func(job *downloadJob) bool) {
+	ring := make([]int, 0, len(s.ring))
+	for _, courseId := range s.ring {
+		kept := make([]*downloadJob, 0, len(s.queues[courseId]))
+		for _, job := range s.queues[courseId] {
+			if drop(job) {
+				job.wg.Done()
+				continue
+			}
+			kept = append(kept, job)
+		}
+		if len(kept) == 0 {
+			delete(s.queues, courseId)
+			continue
+		}
+		s.queues[courseId] = kept
+		ring = append(ring, courseId)
+	}
+	s.ring = ring
+}
+
+// close drops every queued job and stops the workers once they finish their current download
+func (s *scheduler) close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	s.dropLocked(func(job *downloadJob) bool { return true })
+	s.cond.Broadcast()
+}
+
+// next blocks until a job is queued, returning the next course's highest priority job, nil once the scheduler is closed
+func (s *scheduler) next() *downloadJob {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for len(s.ring) == 0 && !s.closed {
+		s.cond.Wait()
+	}
+	if s.closed {
+		return nil
+	}
+	courseId := s.ring[0]
+	s.ring = s.ring[1:]
+	queue := s.queues[courseId]
+	job := queue[0]
+	if len(queue) > 1 {
+		s.queues[courseId] = queue[1:]
+		s.ring = append(s.ring, courseId)
+	} else {
+		delete(s.queues, courseId)
+	}
+	return job
+}
+
+func (s *scheduler) work(c *CanvasClient) {
+	for job := s.next(); job != nil; job = s.next() {
+		c.runDownloadJob(job)
+		job.wg.Done()
+	}
+}
+
+func (c *CanvasClient) runDownloadJob(job *downloadJob) {
+	// drain cancelled jobs without starting them
+	if job.ctx.Err() != nil {
+		return
+	}
+	if err := c.downloadFileNode(job.ctx, job.node, job.m); err != nil {
+		// interrupted downloads are not failures, they're summarised separately
+		if job.ctx.Err() == nil {
+			job.report.AddFailed(job.node.Directory, err)
+		}
+		return
+	}
+	job.report.AddDownloaded(job.node.Directory)
+}
@@ -0,0 +1,128 @@
+package canvas
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aidanaden/canvas-sync/internal/pkg/manifest"
+	"github.com/aidanaden/canvas-sync/internal/pkg/nodes"
+)
+
+// newJobs returns a job per name downloading from server into dir, all under ctx
+func newJobs(t *testing.T, ctx context.Context, server *httptest.Server, wg *sync.WaitGroup, names ...string) []*downloadJob {
+	t.Helper()
+	dir := t.TempDir()
+	m, err := manifest.Load(dir, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	jobs := make([]*downloadJob, 0, len(names))
+	for _, name := range names {
+		wg.Add(1)
+		node := &nodes.FileNode{Url: server.URL + "/" + name, Directory: filepath.Join(dir, name)}
+		jobs = append(jobs, &downloadJob{ctx: ctx, node: node, m: m, report: &SyncReport{}, wg: wg})
+	}
+	return jobs
+}
+
+// recordingServer serves every file, recording the order they're requested in until release is closed
+func recordingServer(t *testing.T, release chan struct{}) (*httptest.Server, func() []string) {
+	var mu sync.Mutex
+	requested := make([]string, 0)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requested = append(requested, strings.TrimPrefix(r.URL.Path, "/"))
+		mu.Unlock()
+		<-release
+		w.Write([]byte("content"))
+	}))
+	t.Cleanup(server.Close)
+	return server, func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string{}, requested...)
+	}
+}
+
+func TestSchedulerTakesTurnsBetweenCourses(t *testing.T) {
+	release := make(chan struct{})
+	close(release)
+	server, requested := recordingServer(t, release)
+	c := NewClient("canvas.example.com", "token")
+	c.scheduler = newScheduler(1, PRIORITY_DEFAULT)
+	defer c.Close()
+
+	var wg sync.WaitGroup
+	ctx := context.Background()
+	// queue both courses before the single worker starts on either
+	c.scheduler.mu.Lock()
+	c.scheduler.started = true
+	c.scheduler.mu.Unlock()
+	stopA := c.scheduler.submit(c, ctx, 1, newJobs(t, ctx, server, &wg, "a1", "a2", "a3"))
+	stopB := c.scheduler.submit(c, ctx, 2, newJobs(t, ctx, server, &wg, "b1"))
+	go c.scheduler.work(c)
+	wg.Wait()
+	stopA()
+	stopB()
+
+	want := "a1 b1 a2 a3"
+	if got := strings.Join(requested(), " "); got != want {
+		t.Errorf("downloaded %s, want %s", got, want)
+	}
+}
+
+func TestSchedulerDropsCancelledJobs(t *testing.T) {
+	release := make(chan struct{})
+	server, requested := recordingServer(t, release)
+	c := NewClient("canvas.example.com", "token")
+	c.scheduler = newScheduler(1, PRIORITY_DEFAULT)
+	defer c.Close()
+
+	var wg sync.WaitGroup
+	ctx, cancel := context.WithCancel(context.Background())
+	stop := c.scheduler.submit(c, ctx, 1, newJobs(t, ctx, server, &wg, "a1", "a2", "a3"))
+	defer stop()
+	// wait for the worker to start the first download, then cancel while it's blocked
+	for len(requested()) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+	close(release)
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("cancelled jobs were never marked done")
+	}
+	if got := requested(); len(got) != 1 {
+		t.Errorf("downloaded %v after cancelling, want only the download already started", got)
+	}
+}
+
+func TestSchedulerClose(t *testing.T) {
+	release := make(chan struct{})
+	close(release)
+	server, requested := recordingServer(t, release)
+	c := NewClient("canvas.example.com", "token")
+	c.scheduler = newScheduler(2, PRIORITY_DEFAULT)
+	c.Close()
+
+	var wg sync.WaitGroup
+	ctx := context.Background()
+	c.scheduler.submit(c, ctx, 1, newJobs(t, ctx, server, &wg, "a1"))
+	wg.Wait()
+	if got := requested(); len(got) != 0 {
+		t.Errorf("downloaded %v after closing", got)
+	}
+}
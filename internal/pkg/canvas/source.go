@@ -0,0 +1,43 @@
+package canvas
+
+import (
+	"context"
+
+	"github.com/aidanaden/canvas-sync/internal/pkg/nodes"
+)
+
+// directories under a course's directory that each source syncs into
+const (
+	FILES_DIR   = "files"
+	MODULES_DIR = "modules"
+	VIDEOS_DIR  = "videos"
+)
+
+// TreeSource is where a course's files are synced from, into Dir under the course's directory
+type TreeSource struct {
+	Dir string
+	// Fetch returns the tree to sync into dir and a function to run after a (non dry-run) sync, which may be nil.
+	// Items that can't be synced but don't fail the course are added to report.
+	Fetch func(ctx context.Context, c *CanvasClient, courseId int, dir string, filter FileFilter, report *SyncReport) (*nodes.DirectoryNode, func() error, error)
+}
+
+// FilesSource syncs the course's files as they're organised in its files tab
+var FilesSource = TreeSource{
+	Dir: FILES_DIR,
+	Fetch: func(ctx context.Context, c *CanvasClient, courseId int, dir string, filter FileFilter, report *SyncReport) (*nodes.DirectoryNode, func() error, error) {
+		root, err := c.GetCourseFileTree(ctx, courseId, dir, filter)
+		return root, nil, err
+	},
+}
+
+// ModulesSource syncs the course's module file items, one folder per module, with a README of every module item
+var ModulesSource = TreeSource{
+	Dir: MODULES_DIR,
+	Fetch: func(ctx context.Context, c *CanvasClient, courseId int, dir string, filter FileFilter, report *SyncReport) (*nodes.DirectoryNode, func() error, error) {
+		tree, err := c.GetCourseModuleTree(ctx, courseId, dir, filter, report)
+		if err != nil {
+			return nil, nil, err
+		}
+		return tree.Root, tree.WriteReadme, nil
+	},
+}
@@ -0,0 +1,99 @@
+package canvas
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"time"
+
+	"github.com/aidanaden/canvas-sync/internal/pkg/manifest"
+	"github.com/aidanaden/canvas-sync/internal/pkg/nodes"
+	"github.com/aidanaden/canvas-sync/internal/pkg/richtext"
+)
+
+const (
+	SYLLABUS_DIR = "syllabus"
+	// name of the syllabus document in the syllabus folder
+	SYLLABUS_DOCUMENT        = "syllabus"
+	SYLLABUS_ATTACHMENTS_DIR = "attachments"
+)
+
+// GetCourseSyllabus returns the html body of a course's syllabus, empty if the course has none
+func (c *CanvasClient) GetCourseSyllabus(ctx context.Context, courseId int) (string, error) {
+	courseUrl := url.URL{
+		Scheme: c.apiPath.Scheme,
+		Host:   c.apiPath.Host,
+		Path:   c.apiPath.Path + fmt.Sprintf("/courses/%d", courseId),
+		RawQuery: url.Values{
+			"include[]": {"syllabus_body"},
+		}.Encode(),
+	}
+	courseJson, _, err := c.get(ctx, courseUrl.String())
+	if err != nil {
+		return "", err
+	}
+	var course struct {
+		SyllabusBody string `json:"syllabus_body"`
+	}
+	if err := json.Unmarshal(courseJson, &course); err != nil {
+		return "", fmt.Errorf("failed to parse syllabus: %w", err)
+	}
+	return course.SyllabusBody, nil
+}
+
+// SyllabusMarkdown returns a course's syllabus as markdown with links relative to canvas made absolute,
+// empty if the course has no syllabus
+func (c *CanvasClient) SyllabusMarkdown(ctx context.Context, courseId int) (string, error) {
+	body, err := c.GetCourseSyllabus(ctx, courseId)
+	if err != nil || body == "" {
+		return "", err
+	}
+	links := &courseLinks{canvasPath: c.canvasPath}
+	return richtext.ToMarkdown(body, links.rewrite(""))
+}
+
+// SyllabusSource saves the course's syllabus with the files it links to
+var SyllabusSource = DocumentSource{
+	Dir: SYLLABUS_DIR,
+	Sync: func(ctx context.Context, c *CanvasClient, course nodes.CourseNode, courseDir string, m *manifest.Manifest, opts DocumentOptions, report *SyncReport) (int, error) {
+		return c.SyncCourseSyllabus(ctx, course.ID, courseDir, m, report)
+	},
+}
+
+// SyncCourseSyllabus writes a course's syllabus into <courseDir>/syllabus/syllabus.html and syllabus.md, downloading
+// the files it links to into attachments/. Canvas doesn't say when a syllabus changed, so it's tracked in the manifest
+// by the hash of the html document and left alone while that's unchanged. Returns 1 if the syllabus was written, 0 if
+// it's unchanged or the course has none.
+func (c *CanvasClient) SyncCourseSyllabus(ctx context.Context, courseId int, courseDir string, m *manifest.Manifest, report *SyncReport) (int, error) {
+	body, err := c.GetCourseSyllabus(ctx, courseId)
+	if err != nil || body == "" {
+		return 0, err
+	}
+	dir := filepath.Join(courseDir, SYLLABUS_DIR)
+	links := c.newCourseLinks(m, courseDir)
+	local := c.downloadAttachments(ctx, c.linkedFiles(ctx, body, links, report), filepath.Join(dir, SYLLABUS_ATTACHMENTS_DIR), m, report)
+	base := filepath.Join(dir, SYLLABUS_DOCUMENT)
+	htmlPath := base + ".html"
+	document, markdown, err := renderDocument(base, nil, "Syllabus", body, links.with(local))
+	if err != nil {
+		return 0, err
+	}
+	if entry := m.Get(htmlPath); entry != nil && entry.Hash == documentHash(document) && documentExists(htmlPath) {
+		return 0, nil
+	}
+	hash, size, err := saveDocument(base, document, markdown)
+	if err != nil {
+		return 0, err
+	}
+	m.Put(htmlPath, manifest.Entry{
+		FileID:     courseId,
+		Size:       size,
+		UpdatedAt:  time.Now(),
+		CanvasPath: SYLLABUS_DIR,
+		Hash:       hash,
+	})
+	report.AddDownloaded(htmlPath)
+	return 1, nil
+}
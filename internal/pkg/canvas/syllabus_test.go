@@ -0,0 +1,74 @@
+package canvas
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/aidanaden/canvas-sync/internal/pkg/manifest"
+)
+
+func TestSyncCourseSyllabus(t *testing.T) {
+	body := "<p>Week 1: introduction</p>"
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v1/courses/1", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("include[]") != "syllabus_body" {
+			t.Errorf("requested the course without its syllabus: %s", r.URL)
+		}
+		fmt.Fprintf(w, `{"id": 1, "syllabus_body": %q}`, body)
+	})
+	c := newAPIClient(t, mux)
+	courseDir := t.TempDir()
+	m, err := manifest.Load(courseDir, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	htmlPath := filepath.Join(courseDir, SYLLABUS_DIR, SYLLABUS_DOCUMENT+".html")
+	sync := func() (int, *SyncReport) {
+		t.Helper()
+		report := &SyncReport{}
+		written, err := c.SyncCourseSyllabus(context.Background(), 1, courseDir, m, report)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return written, report
+	}
+
+	if written, report := sync(); written != 1 || len(report.Downloaded) != 1 {
+		t.Fatalf("wrote %d documents, reported %v, want the syllabus", written, report.Downloaded)
+	}
+	entry := m.Get(htmlPath)
+	if entry == nil {
+		t.Fatalf("%s isn't in the manifest", htmlPath)
+	}
+	if hash, err := manifest.HashFile(htmlPath); err != nil || entry.Hash != hash {
+		t.Errorf("manifest hash is %s, want the html document's %s (%v)", entry.Hash, hash, err)
+	}
+
+	if written, report := sync(); written != 0 || len(report.Downloaded) != 0 {
+		t.Errorf("rewrote the unchanged syllabus (%d written, reported %v)", written, report.Downloaded)
+	}
+
+	body = "<p>Week 1: introduction</p><p>Week 2: recursion</p>"
+	if written, _ := sync(); written != 1 {
+		t.Errorf("wrote %d documents after the syllabus changed, want 1", written)
+	}
+	markdown, err := os.ReadFile(filepath.Join(courseDir, SYLLABUS_DIR, SYLLABUS_DOCUMENT+".md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(markdown), "Week 2: recursion") {
+		t.Errorf("syllabus wasn't updated:\n%s", markdown)
+	}
+
+	if err := os.Remove(htmlPath); err != nil {
+		t.Fatal(err)
+	}
+	if written, _ := sync(); written != 1 {
+		t.Errorf("wrote %d documents after the syllabus was deleted locally, want 1", written)
+	}
+}
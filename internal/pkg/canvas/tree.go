@@ -0,0 +1,139 @@
+package canvas
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/aidanaden/canvas-sync/internal/pkg/nodes"
+	"github.com/aidanaden/canvas-sync/internal/pkg/utils"
+)
+
+func (c *CanvasClient) getCourseListUrl(courseId int, resource string) url.URL {
+	return url.URL{
+		Scheme: c.apiPath.Scheme,
+		Host:   c.apiPath.Host,
+		Path:   c.apiPath.Path + fmt.Sprintf("/courses/%d/%s", courseId, resource),
+	}
+}
+
+// filesUnavailable reports whether err means the course's file listings are hidden from the user (e.g. a hidden
+// files tab), which canvas answers with any of 401, 403 or 404
+func filesUnavailable(err error) bool {
+	return errors.Is(err, ErrUnauthorized) || errors.Is(err, ErrForbidden) || errors.Is(err, ErrNotFound)
+}
+
+// GetCourseFileTree returns a course's folders and files not skipped by filter as a tree rooted at dir, built from
+// the flat course-wide listings in a few paginated requests. Falls back to walking folder by folder (never fetching
+// excluded folders) only if the flat listings aren't available to the user, any other error is returned.
+func (c *CanvasClient) GetCourseFileTree(ctx context.Context, courseId int, dir string, filter FileFilter) (*nodes.DirectoryNode, error) {
+	root, err := c.getFlatCourseFileTree(ctx, courseId, dir)
+	if err == nil {
+		filterTree(root, filter)
+		return root, nil
+	}
+	if !filesUnavailable(err) {
+		return nil, err
+	}
+	root, err = c.GetCourseRootFolder(ctx, courseId)
+	if err != nil {
+		return nil, err
+	}
+	root.Name = dir
+	if err := c.RecurseDirectoryNode(ctx, root, nil, filter); err != nil {
+		return nil, err
+	}
+	setTreeDirectories(root, dir)
+	filterTree(root, filter)
+	return root, nil
+}
+
+func (c *CanvasClient) getFlatCourseFileTree(ctx context.Context, courseId int, dir string) (*nodes.DirectoryNode, error) {
+	folders, err := getPaginated(ctx, c, c.getCourseListUrl(courseId, "folders"), extractFoldersFromString)
+	if err != nil {
+		return nil, err
+	}
+	files, err := getPaginated(ctx, c, c.getCourseListUrl(courseId, "files"), extractFilesFromString)
+	if err != nil {
+		return nil, err
+	}
+	return buildFileTree(folders, files, dir)
+}
+
+// buildFileTree links folders to their parents and files to their folders via parent_folder_id/folder_id
+func buildFileTree(folders []*nodes.DirectoryNode, files []*nodes.FileNode, dir string) (*nodes.DirectoryNode, error) {
+	byId := make(map[int]*nodes.DirectoryNode, len(folders))
+	for _, folder := range folders {
+		byId[folder.ID] = folder
+	}
+	var root *nodes.DirectoryNode
+	for _, folder := range folders {
+		if folder.ParentFolderID == 0 {
+			if root != nil {
+				return nil, fmt.Errorf("course has multiple root folders (%d and %d)", root.ID, folder.ID)
+			}
+			root = folder
+			continue
+		}
+		// parents hidden from the user are never walked, neither are their children
+		if parent, ok := byId[folder.ParentFolderID]; ok {
+			parent.FolderNodes = append(parent.FolderNodes, folder)
+		}
+	}
+	if root == nil {
+		return nil, fmt.Errorf("course has no root folder")
+	}
+	for _, file := range files {
+		if folder, ok := byId[file.FolderID]; ok {
+			folder.FileNodes = append(folder.FileNodes, file)
+		}
+	}
+	setTreeDirectories(root, dir)
+	return root, nil
+}
+
+// setTreeDirectories sets the local paths of everything under node (located at dir), using sanitised names.
+// Names that collide in a folder (case-insensitively, as on windows and macos) are resolved deterministically:
+// the item with the lowest canvas id keeps the name, the others get their id appended.
+func setTreeDirectories(node *nodes.DirectoryNode, dir string) {
+	node.Directory = dir
+	taken := make(map[string]bool)
+	claim := func(name string, id int, withSuffix func(name string, suffix string) string) string {
+		if taken[strings.ToLower(name)] {
+			name = withSuffix(name, fmt.Sprintf(" (%d)", id))
+		}
+		taken[strings.ToLower(name)] = true
+		return name
+	}
+	appendSuffix := func(name string, suffix string) string {
+		return name + suffix
+	}
+
+	folders := append([]*nodes.DirectoryNode{}, node.FolderNodes...)
+	sort.SliceStable(folders, func(i, j int) bool {
+		return folders[i].ID < folders[j].ID
+	})
+	for _, folder := range folders {
+		name := claim(utils.SanitiseFilename(folder.Name), folder.ID, appendSuffix)
+		setTreeDirectories(folder, filepath.Join(dir, name))
+	}
+	files := append([]*nodes.FileNode{}, node.FileNodes...)
+	sort.SliceStable(files, func(i, j int) bool {
+		return files[i].ID < files[j].ID
+	})
+	for _, file := range files {
+		if file == nil {
+			continue
+		}
+		name := claim(utils.SanitiseFilename(file.Display_name), file.ID, utils.WithNameSuffix)
+		file.Directory = filepath.Join(dir, name)
+		file.CanvasPath = fileCanvasPath(node, file)
+	}
+	sort.SliceStable(node.FolderNodes, func(i, j int) bool {
+		return node.FolderNodes[i].Name < node.FolderNodes[j].Name
+	})
+}
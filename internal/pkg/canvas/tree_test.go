@@ -0,0 +1,143 @@
+package canvas
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	"github.com/aidanaden/canvas-sync/internal/pkg/nodes"
+)
+
+func TestBuildFileTree(t *testing.T) {
+	folders := []*nodes.DirectoryNode{
+		{ID: 1, Name: "course files", FullName: "course files"},
+		{ID: 2, ParentFolderID: 1, Name: "Week 1", FullName: "course files/Week 1"},
+		{ID: 3, ParentFolderID: 2, Name: "Labs", FullName: "course files/Week 1/Labs"},
+		// parent hidden from the user
+		{ID: 4, ParentFolderID: 99, Name: "Orphan", FullName: "course files/Hidden/Orphan"},
+	}
+	files := []*nodes.FileNode{
+		{ID: 10, FolderID: 1, Display_name: "syllabus.pdf"},
+		{ID: 11, FolderID: 3, Display_name: "lab1.pdf"},
+		{ID: 12, FolderID: 4, Display_name: "orphan.pdf"},
+	}
+	root, err := buildFileTree(folders, files, "/data/CS1010/files")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if root.ID != 1 || len(root.FolderNodes) != 1 || len(root.FileNodes) != 1 {
+		t.Fatalf("root has %d folders and %d files, want 1 and 1", len(root.FolderNodes), len(root.FileNodes))
+	}
+	lab := root.FolderNodes[0].FolderNodes[0].FileNodes[0]
+	if want := filepath.FromSlash("/data/CS1010/files/Week 1/Labs/lab1.pdf"); lab.Directory != want {
+		t.Errorf("lab1.pdf is at %s, want %s", lab.Directory, want)
+	}
+	if want := "Week 1/Labs/lab1.pdf"; lab.CanvasPath != want {
+		t.Errorf("lab1.pdf canvas path is %s, want %s", lab.CanvasPath, want)
+	}
+}
+
+func TestBuildFileTreeRootErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		folders []*nodes.DirectoryNode
+	}{
+		{"no root", []*nodes.DirectoryNode{{ID: 2, ParentFolderID: 1}}},
+		{"multiple roots", []*nodes.DirectoryNode{{ID: 1}, {ID: 2}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := buildFileTree(tt.folders, nil, "files"); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
+
+func TestSetTreeDirectories(t *testing.T) {
+	root := &nodes.DirectoryNode{
+		FullName: "course files",
+		FolderNodes: []*nodes.DirectoryNode{
+			{ID: 8, Name: "notes", FullName: "course files/notes"},
+			{ID: 5, Name: "Notes", FullName: "course files/Notes"},
+			{ID: 6, Name: "Week 1: Intro", FullName: "course files/Week 1: Intro"},
+		},
+		FileNodes: []*nodes.FileNode{
+			{ID: 21, Display_name: "slides.pdf"},
+			{ID: 20, Display_name: "Slides.pdf"},
+			{ID: 22, Display_name: "a/b.pdf"},
+		},
+	}
+	setTreeDirectories(root, "files")
+
+	want := map[int]string{
+		5:  "files/Notes",
+		8:  "files/notes (8)",
+		6:  "files/Week 1_ Intro",
+		20: "files/Slides.pdf",
+		21: "files/slides (21).pdf",
+	}
+	for _, folder := range root.FolderNodes {
+		if expected, ok := want[folder.ID]; ok && folder.Directory != filepath.FromSlash(expected) {
+			t.Errorf("folder %d is at %s, want %s", folder.ID, folder.Directory, expected)
+		}
+	}
+	for _, file := range root.FileNodes {
+		if expected, ok := want[file.ID]; ok && file.Directory != filepath.FromSlash(expected) {
+			t.Errorf("file %d is at %s, want %s", file.ID, file.Directory, expected)
+		}
+	}
+
+	// names only depend on ids, not on listing order
+	reversed := &nodes.DirectoryNode{
+		FullName:  "course files",
+		FileNodes: []*nodes.FileNode{{ID: 20, Display_name: "Slides.pdf"}, {ID: 21, Display_name: "slides.pdf"}},
+	}
+	setTreeDirectories(reversed, "files")
+	if got := reversed.FileNodes[1].Directory; got != filepath.FromSlash(want[21]) {
+		t.Errorf("file 21 is at %s after reordering, want %s", got, want[21])
+	}
+}
+
+func TestGetCourseFileTreeFallback(t *testing.T) {
+	tests := []struct {
+		name         string
+		status       int
+		wantFallback bool
+	}{
+		{"files tab hidden", http.StatusForbidden, true},
+		{"files tab unauthorized", http.StatusUnauthorized, true},
+		{"listing not found", http.StatusNotFound, true},
+		{"server error", http.StatusInternalServerError, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var walked atomic.Bool
+			mux := http.NewServeMux()
+			mux.HandleFunc("/api/v1/courses/1/folders", func(w http.ResponseWriter, r *http.Request) {
+				http.Error(w, `{"message": "failed"}`, tt.status)
+			})
+			mux.HandleFunc("/api/v1/courses/1/folders/root", func(w http.ResponseWriter, r *http.Request) {
+				walked.Store(true)
+				fmt.Fprint(w, `{"id": 1, "name": "course files", "full_name": "course files"}`)
+			})
+			c := newAPIClient(t, mux)
+
+			_, err := c.GetCourseFileTree(context.Background(), 1, "files", FileFilter{})
+			if walked.Load() != tt.wantFallback {
+				t.Errorf("walked folder by folder: %v, want %v", walked.Load(), tt.wantFallback)
+			}
+			if tt.wantFallback && err != nil {
+				t.Errorf("fallback failed: %s", err)
+			}
+			var apiErr *APIError
+			if !tt.wantFallback && (!errors.As(err, &apiErr) || apiErr.StatusCode != tt.status) {
+				t.Errorf("got error %v, want the listing's %d", err, tt.status)
+			}
+		})
+	}
+}
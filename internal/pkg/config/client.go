@@ -0,0 +1,104 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/aidanaden/canvas-sync/internal/pkg/canvas"
+	"github.com/aidanaden/canvas-sync/internal/pkg/nodes"
+	"github.com/aidanaden/canvas-sync/internal/pkg/utils"
+	"github.com/pterm/pterm"
+	"github.com/spf13/viper"
+)
+
+// ClientOptions returns the canvas client options configured via flags/config file
+func ClientOptions() []canvas.ClientOption {
+	retryPolicy := canvas.DefaultRetryPolicy
+	if viper.IsSet("max_retries") {
+		// max_retries excludes the first attempt
+		retryPolicy.MaxAttempts = viper.GetInt("max_retries") + 1
+	}
+	if viper.IsSet("retry_base_delay") {
+		retryPolicy.BaseDelay = viper.GetDuration("retry_base_delay")
+	}
+	if viper.IsSet("retry_max_delay") {
+		retryPolicy.MaxDelay = viper.GetDuration("retry_max_delay")
+	}
+	if viper.IsSet("retry_jitter") {
+		retryPolicy.Jitter = viper.GetFloat64("retry_jitter")
+	}
+	if viper.IsSet("retry_statuses") {
+		retryPolicy.RetryStatuses = viper.GetIntSlice("retry_statuses")
+	}
+	priority, err := canvas.ParsePriority(viper.GetString("priority"))
+	if err != nil {
+		pterm.Error.Printfln("Invalid config: %s", err.Error())
+		os.Exit(1)
+	}
+	return []canvas.ClientOption{
+		canvas.WithMaxConcurrency(viper.GetInt("max_concurrency")),
+		canvas.WithRetryPolicy(retryPolicy),
+		canvas.WithScheduler(viper.GetInt("workers"), priority),
+		canvas.WithDataDir(utils.GetExpandedHomeDirectoryPath(viper.GetString("data_dir"))),
+		canvas.WithCourseFilter(canvas.CourseFilter{
+			IncludeCompleted: viper.GetBool("include_completed"),
+			AllEnrollments:   viper.GetBool("all_enrollments"),
+			Term:             viper.GetString("term"),
+		}),
+	}
+}
+
+// courseConfigKeys returns the config keys a course's settings can be under, by course code or id
+func courseConfigKeys(course nodes.CourseNode) []string {
+	return []string{
+		"courses." + strings.ToLower(course.CourseCode),
+		"courses." + strings.ToLower(course.OriginalCourseCode),
+		fmt.Sprintf("courses.%d", course.ID),
+	}
+}
+
+// FileFilter returns the file sync filter for a course: the include/exclude/type/max_size flags (or top-level config
+// values) combined with the course's own values under "courses" in the config file
+func FileFilter(course nodes.CourseNode) (canvas.FileFilter, error) {
+	filter := canvas.FileFilter{
+		Include: viper.GetStringSlice("include"),
+		Exclude: viper.GetStringSlice("exclude"),
+		Types:   splitList(viper.GetStringSlice("type")),
+	}
+	maxSize := viper.GetString("max_size")
+	for _, key := range courseConfigKeys(course) {
+		if key == "courses." || !viper.IsSet(key) {
+			continue
+		}
+		filter.Include = append(filter.Include, viper.GetStringSlice(key+".include")...)
+		filter.Exclude = append(filter.Exclude, viper.GetStringSlice(key+".exclude")...)
+		filter.Types = append(filter.Types, splitList(viper.GetStringSlice(key+".type"))...)
+		// the course's limit takes precedence
+		if viper.IsSet(key + ".max_size") {
+			maxSize = viper.GetString(key + ".max_size")
+		}
+		break
+	}
+	if maxSize != "" {
+		size, err := utils.ParseSize(maxSize)
+		if err != nil {
+			return canvas.FileFilter{}, err
+		}
+		filter.MaxSize = size
+	}
+	return filter, nil
+}
+
+// splitList splits comma separated values e.g. "pdf,pptx" from the config file
+func splitList(values []string) []string {
+	split := make([]string, 0, len(values))
+	for _, value := range values {
+		for _, v := range strings.Split(value, ",") {
+			if v = strings.TrimSpace(v); v != "" {
+				split = append(split, v)
+			}
+		}
+	}
+	return split
+}
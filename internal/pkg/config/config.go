@@ -60,6 +60,19 @@ func SaveConfig(filepath string, config *Config, verbose bool) error {
 	return nil
 }
 
+// maskSecret hides all but the first and last characters of a secret
+func maskSecret(secret string) string {
+	var rawTruncated = []byte{}
+	for i := 0; i < len(secret); i++ {
+		if i == 0 || i == len(secret)-1 {
+			rawTruncated = append(rawTruncated, []byte(secret)[i])
+		} else {
+			rawTruncated = append(rawTruncated, '*')
+		}
+	}
+	return string(rawTruncated)
+}
+
 func PrintConfig(path string, config *Config) error {
 	td := [][]string{
 		{pterm.FgCyan.Sprint("data_dir"), pterm.FgGreen.Sprint(config.DataDir)},
@@ -69,18 +82,9 @@ func PrintConfig(path string, config *Config) error {
 		td = append(td, []string{pterm.FgCyan.Sprint("canvas_username"), pterm.FgGreen.Sprint(config.Username)})
 	}
 	if config.Password != "" {
-		var rawTruncated = []byte{}
-		for i := 0; i < len(config.Password); i++ {
-			if i == 0 || i == len(config.Password)-1 {
-				rawTruncated = append(rawTruncated, []byte(config.Password)[i])
-			} else {
-				rawTruncated = append(rawTruncated, '*')
-			}
-		}
-		truncated := string(rawTruncated)
-		td = append(td, []string{pterm.FgCyan.Sprint("canvas_password"), pterm.FgGreen.Sprint(truncated)})
+		td = append(td, []string{pterm.FgCyan.Sprint("canvas_password"), pterm.FgGreen.Sprint(maskSecret(config.Password))})
 	}
-	td = append(td, []string{pterm.FgCyan.Sprint("access_token"), pterm.FgGreen.Sprint(config.AccessToken)})
+	td = append(td, []string{pterm.FgCyan.Sprint("access_token"), pterm.FgGreen.Sprint(maskSecret(config.AccessToken))})
 
 	tablePrint, err := pterm.DefaultTable.WithHasHeader().WithData(td).Srender()
 	if err != nil {
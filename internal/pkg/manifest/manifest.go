@@ -0,0 +1,207 @@
+package manifest
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+const MANIFEST_FILE = ".canvas-sync-manifest.json"
+
+// Entry records a canvas file downloaded to the course directory
+type Entry struct {
+	FileID    int       `json:"file_id"`
+	FolderID  int       `json:"folder_id"`
+	Size      int64     `json:"size"`
+	UpdatedAt time.Time `json:"updated_at"`
+	// path relative to the course directory, always slash separated
+	Path string `json:"path"`
+	// original path on canvas the (sanitised) local path maps to
+	CanvasPath string `json:"canvas_path,omitempty"`
+	// sha256 of the downloaded contents
+	Hash string `json:"hash"`
+}
+
+// Manifest is the source of truth for what has been downloaded for a course, safe for concurrent use
+type Manifest struct {
+	mu        sync.Mutex
+	courseDir string
+	CourseID  int               `json:"course_id"`
+	Files     map[string]*Entry `json:"files"`
+}
+
+func manifestPath(courseDir string) string {
+	return filepath.Join(courseDir, MANIFEST_FILE)
+}
+
+// CourseMismatchError is returned when loading a manifest that was written for another course
+type CourseMismatchError struct {
+	CourseDir string
+	CourseID  int
+	Found     int
+}
+
+func (e *CourseMismatchError) Error() string {
+	return fmt.Sprintf("%s holds files of course %d, not course %d", e.CourseDir, e.Found, e.CourseID)
+}
+
+// Load reads the manifest in courseDir, returning an empty manifest if none exists yet. Fails with a
+// *CourseMismatchError if the manifest belongs to another course, so one course never prunes or moves another's files.
+func Load(courseDir string, courseId int) (*Manifest, error) {
+	m := &Manifest{
+		courseDir: courseDir,
+		CourseID:  courseId,
+		Files:     make(map[string]*Entry),
+	}
+	raw, err := os.ReadFile(manifestPath(courseDir))
+	if errors.Is(err, os.ErrNotExist) {
+		return m, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(raw, m); err != nil {
+		return nil, err
+	}
+	if m.CourseID != courseId {
+		// manifests always record their course, 0 is only left by a manifest without one
+		if m.CourseID != 0 {
+			return nil, &CourseMismatchError{CourseDir: courseDir, CourseID: courseId, Found: m.CourseID}
+		}
+		m.CourseID = courseId
+	}
+	if m.Files == nil {
+		m.Files = make(map[string]*Entry)
+	}
+	return m, nil
+}
+
+// CourseID returns the id of the course whose manifest is in courseDir, 0 if there is none
+func CourseID(courseDir string) (int, error) {
+	raw, err := os.ReadFile(manifestPath(courseDir))
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	var stored struct {
+		CourseID int `json:"course_id"`
+	}
+	if err := json.Unmarshal(raw, &stored); err != nil {
+		return 0, err
+	}
+	return stored.CourseID, nil
+}
+
+// Save writes the manifest atomically so an interrupted save never corrupts it
+func (m *Manifest) Save() error {
+	m.mu.Lock()
+	raw, err := json.MarshalIndent(m, "", "  ")
+	m.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(m.courseDir, 0755); err != nil {
+		return err
+	}
+	tmp := manifestPath(m.courseDir) + ".tmp"
+	if err := os.WriteFile(tmp, raw, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, manifestPath(m.courseDir))
+}
+
+// key returns the manifest key for an absolute local path
+func (m *Manifest) key(localPath string) string {
+	rel, err := filepath.Rel(m.courseDir, localPath)
+	if err != nil {
+		return filepath.ToSlash(localPath)
+	}
+	return filepath.ToSlash(rel)
+}
+
+// LocalPath returns the absolute local path of an entry
+func (m *Manifest) LocalPath(entry *Entry) string {
+	return filepath.Join(m.courseDir, filepath.FromSlash(entry.Path))
+}
+
+// Get returns the entry for a local path, nil if it was never downloaded
+func (m *Manifest) Get(localPath string) *Entry {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.Files[m.key(localPath)]
+}
+
+func (m *Manifest) Put(localPath string, entry Entry) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	entry.Path = m.key(localPath)
+	m.Files[entry.Path] = &entry
+}
+
+func (m *Manifest) Remove(localPath string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.Files, m.key(localPath))
+}
+
+// Move re-keys the entry at oldPath to newPath after the local file was renamed
+func (m *Manifest) Move(oldPath string, newPath string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	entry, ok := m.Files[m.key(oldPath)]
+	if !ok {
+		return
+	}
+	delete(m.Files, entry.Path)
+	entry.Path = m.key(newPath)
+	m.Files[entry.Path] = entry
+}
+
+// Entries returns a snapshot of the entries under a local directory
+func (m *Manifest) Entries(dir string) []Entry {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	prefix := m.key(dir) + "/"
+	entries := make([]Entry, 0)
+	for _, entry := range m.Files {
+		if strings.HasPrefix(entry.Path, prefix) {
+			entries = append(entries, *entry)
+		}
+	}
+	return entries
+}
+
+// ByFileID returns a snapshot of the entries under a local directory, indexed by canvas file id
+func (m *Manifest) ByFileID(dir string) map[int]Entry {
+	entries := make(map[int]Entry)
+	for _, entry := range m.Entries(dir) {
+		if entry.FileID != 0 {
+			entries[entry.FileID] = entry
+		}
+	}
+	return entries
+}
+
+// HashFile returns the sha256 of a local file's contents
+func HashFile(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
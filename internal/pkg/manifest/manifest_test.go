@@ -0,0 +1,52 @@
+package manifest
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadChecksCourse(t *testing.T) {
+	courseDir := t.TempDir()
+	m, err := Load(courseDir, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.Put(filepath.Join(courseDir, "files", "a.pdf"), Entry{FileID: 10})
+	if err := m.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Load(courseDir, 1); err != nil {
+		t.Errorf("loading the course's own manifest failed: %s", err)
+	}
+	_, err = Load(courseDir, 2)
+	var mismatch *CourseMismatchError
+	if !errors.As(err, &mismatch) || mismatch.Found != 1 || mismatch.CourseID != 2 {
+		t.Errorf("loading another course's manifest returned %v, want a CourseMismatchError", err)
+	}
+	if id, err := CourseID(courseDir); err != nil || id != 1 {
+		t.Errorf("CourseID() = %d, %v, want 1", id, err)
+	}
+}
+
+func TestLoadAdoptsManifestWithoutCourse(t *testing.T) {
+	courseDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(courseDir, MANIFEST_FILE), []byte(`{"files":{}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	m, err := Load(courseDir, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.CourseID != 3 {
+		t.Errorf("CourseID = %d, want 3", m.CourseID)
+	}
+}
+
+func TestCourseIDWithoutManifest(t *testing.T) {
+	if id, err := CourseID(t.TempDir()); err != nil || id != 0 {
+		t.Errorf("CourseID() = %d, %v, want 0", id, err)
+	}
+}
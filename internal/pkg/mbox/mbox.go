@@ -0,0 +1,158 @@
+package mbox
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Message is a single email in a mailbox
+type Message struct {
+	ID       string
+	FromName string
+	From     string
+	ToName   string
+	To       string
+	Subject  string
+	Date     time.Time
+	Text     string
+	Html     string
+	// local paths of files attached to the message
+	Attachments []string
+}
+
+// lines that mbox readers would take as the start of a new message, escaped with a leading ">" (mboxrd)
+var fromLine = regexp.MustCompile(`(?m)^(>*From )`)
+
+// Write writes messages as an mbox mailbox with unix line endings, in the given order
+func Write(w io.Writer, messages []Message) error {
+	buffered := bufio.NewWriter(w)
+	for _, message := range messages {
+		raw, err := message.bytes()
+		if err != nil {
+			return err
+		}
+		// mbox files use unix line endings throughout, like the From lines separating messages
+		raw = bytes.ReplaceAll(raw, []byte("\r\n"), []byte("\n"))
+		fmt.Fprintf(buffered, "From canvas-sync %s\n", message.Date.UTC().Format(time.ANSIC))
+		buffered.Write(fromLine.ReplaceAll(raw, []byte(">$1")))
+		buffered.WriteString("\n\n")
+	}
+	return buffered.Flush()
+}
+
+// WriteFile writes messages to an mbox file at path, replacing it if it exists
+func WriteFile(path string, messages []Message) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := Write(file, messages); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+func address(name string, email string) string {
+	if name == "" {
+		return email
+	}
+	return fmt.Sprintf("%s <%s>", mime.QEncoding.Encode("utf-8", name), email)
+}
+
+func writeQuotedPrintable(w io.Writer, content string) error {
+	qp := quotedprintable.NewWriter(w)
+	if _, err := qp.Write([]byte(content)); err != nil {
+		return err
+	}
+	return qp.Close()
+}
+
+// bytes renders the message as multipart/mixed with a text and html alternative followed by its attachments
+func (m Message) bytes() ([]byte, error) {
+	var b bytes.Buffer
+	mixed := multipart.NewWriter(&b)
+	headers := []string{
+		"From: " + address(m.FromName, m.From),
+		"To: " + address(m.ToName, m.To),
+		"Subject: " + mime.QEncoding.Encode("utf-8", m.Subject),
+		"Date: " + m.Date.Format(time.RFC1123Z),
+		"Message-ID: <" + m.ID + ">",
+		"MIME-Version: 1.0",
+		"Content-Type: multipart/mixed; boundary=" + mixed.Boundary(),
+	}
+	b.WriteString(strings.Join(headers, "\r\n") + "\r\n\r\n")
+
+	alternativeBody := &bytes.Buffer{}
+	alternative := multipart.NewWriter(alternativeBody)
+	for _, part := range []struct {
+		contentType string
+		content     string
+	}{{"text/plain", m.Text}, {"text/html", m.Html}} {
+		w, err := alternative.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType + "; charset=utf-8"},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		if err := writeQuotedPrintable(w, part.content); err != nil {
+			return nil, err
+		}
+	}
+	if err := alternative.Close(); err != nil {
+		return nil, err
+	}
+	w, err := mixed.CreatePart(textproto.MIMEHeader{
+		"Content-Type": {"multipart/alternative; boundary=" + alternative.Boundary()},
+	})
+	if err != nil {
+		return nil, err
+	}
+	w.Write(alternativeBody.Bytes())
+
+	for _, attachment := range m.Attachments {
+		content, err := os.ReadFile(attachment)
+		if err != nil {
+			return nil, err
+		}
+		name := filepath.Base(attachment)
+		contentType := mime.TypeByExtension(filepath.Ext(name))
+		if contentType == "" {
+			contentType = "application/octet-stream"
+		}
+		w, err := mixed.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {mime.FormatMediaType(contentType, map[string]string{"name": name})},
+			"Content-Disposition":       {mime.FormatMediaType("attachment", map[string]string{"filename": name})},
+			"Content-Transfer-Encoding": {"base64"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		encoded := base64.StdEncoding.EncodeToString(content)
+		for len(encoded) > 76 {
+			io.WriteString(w, encoded[:76]+"\r\n")
+			encoded = encoded[76:]
+		}
+		io.WriteString(w, encoded+"\r\n")
+	}
+	if err := mixed.Close(); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
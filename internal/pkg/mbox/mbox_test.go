@@ -0,0 +1,208 @@
+package mbox
+
+import (
+	"bytes"
+	"encoding/base64"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+var quotedFromLine = regexp.MustCompile(`(?m)^>(>*From )`)
+
+// readMbox splits an mboxrd mailbox into its messages, unquoting their "From " lines
+func readMbox(t *testing.T, mailbox string) []*mail.Message {
+	t.Helper()
+	if !strings.HasPrefix(mailbox, "From ") {
+		t.Fatalf("mailbox doesn't start with a From line: %q", mailbox)
+	}
+	messages := make([]*mail.Message, 0)
+	for _, raw := range strings.Split(mailbox, "\nFrom ") {
+		// drop the rest of the From line
+		_, raw, _ = strings.Cut(raw, "\n")
+		raw = quotedFromLine.ReplaceAllString(raw, "$1")
+		message, err := mail.ReadMessage(strings.NewReader(raw))
+		if err != nil {
+			t.Fatal(err)
+		}
+		messages = append(messages, message)
+	}
+	return messages
+}
+
+// parts returns the decoded parts of a multipart body, keyed by content type
+func parts(t *testing.T, contentType string, body io.Reader) map[string]string {
+	t.Helper()
+	_, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded := make(map[string]string)
+	reader := multipart.NewReader(body, params["boundary"])
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			return decoded
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		mediaType, _, _ := mime.ParseMediaType(part.Header.Get("Content-Type"))
+		// multipart.Part already decodes quoted-printable
+		content, err := io.ReadAll(part)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if strings.HasPrefix(mediaType, "multipart/") {
+			for nestedType, nested := range parts(t, part.Header.Get("Content-Type"), bytes.NewReader(content)) {
+				decoded[nestedType] = nested
+			}
+			continue
+		}
+		if part.Header.Get("Content-Transfer-Encoding") == "base64" {
+			if content, err = io.ReadAll(base64.NewDecoder(base64.StdEncoding, bytes.NewReader(content))); err != nil {
+				t.Fatal(err)
+			}
+		}
+		decoded[mediaType] = string(content)
+	}
+}
+
+func TestWriteQuotesFromLines(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+	}{
+		{"from line", "Hi\nFrom the teaching team"},
+		{"quoted from line", "Hi\n>From the teaching team"},
+		{"doubly quoted from line", "Hi\n>>From the teaching team"},
+		{"first line", "From the teaching team"},
+		{"not a from line", "Fromage\n From here\nfrom here"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var b bytes.Buffer
+			message := Message{ID: "1@canvas", From: "a@example.com", To: "b@example.com", Subject: "hi", Text: tt.text}
+			if err := Write(&b, []Message{message, message}); err != nil {
+				t.Fatal(err)
+			}
+			for _, line := range strings.Split(b.String(), "\n")[1:] {
+				if strings.HasPrefix(line, "From ") && !strings.HasPrefix(line, "From canvas-sync ") {
+					t.Errorf("unquoted From line %q", line)
+				}
+			}
+			messages := readMbox(t, b.String())
+			if len(messages) != 2 {
+				t.Fatalf("read %d messages, want 2", len(messages))
+			}
+			got := parts(t, messages[0].Header.Get("Content-Type"), messages[0].Body)["text/plain"]
+			if got != tt.text {
+				t.Errorf("read back %q, want %q", got, tt.text)
+			}
+		})
+	}
+}
+
+func TestWriteMessage(t *testing.T) {
+	attachment := filepath.Join(t.TempDir(), "notes.pdf")
+	if err := os.WriteFile(attachment, bytes.Repeat([]byte{0, 1, 2, 255}, 100), 0644); err != nil {
+		t.Fatal(err)
+	}
+	date := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	message := Message{
+		ID:          "42@canvas.example.com",
+		FromName:    "Prof. Tan",
+		From:        "tan@example.com",
+		To:          "me@example.com",
+		Subject:     "Week 1 – slides",
+		Date:        date,
+		Text:        "See the slides",
+		Html:        "<p>See the <a href=\"notes.pdf\">slides</a></p>",
+		Attachments: []string{attachment},
+	}
+	var b bytes.Buffer
+	if err := Write(&b, []Message{message}); err != nil {
+		t.Fatal(err)
+	}
+	if want := "From canvas-sync Fri Mar  1 10:00:00 2024\n"; !strings.HasPrefix(b.String(), want) {
+		t.Errorf("mailbox starts with %q, want %q", strings.SplitAfter(b.String(), "\n")[0], want)
+	}
+
+	read := readMbox(t, b.String())[0]
+	decoder := new(mime.WordDecoder)
+	subject, err := decoder.DecodeHeader(read.Header.Get("Subject"))
+	if err != nil || subject != message.Subject {
+		t.Errorf("subject is %q, want %q", subject, message.Subject)
+	}
+	from, err := read.Header.AddressList("From")
+	if err != nil || len(from) != 1 || from[0].Name != message.FromName || from[0].Address != message.From {
+		t.Errorf("from is %v, want %s <%s>", from, message.FromName, message.From)
+	}
+	if got, err := read.Header.Date(); err != nil || !got.Equal(date) {
+		t.Errorf("date is %s, want %s", got, date)
+	}
+	if got := read.Header.Get("Message-ID"); got != "<"+message.ID+">" {
+		t.Errorf("message id is %s", got)
+	}
+
+	decoded := parts(t, read.Header.Get("Content-Type"), read.Body)
+	if decoded["text/plain"] != message.Text {
+		t.Errorf("text is %q, want %q", decoded["text/plain"], message.Text)
+	}
+	if decoded["text/html"] != message.Html {
+		t.Errorf("html is %q, want %q", decoded["text/html"], message.Html)
+	}
+	content, _ := os.ReadFile(attachment)
+	if got := decoded["application/pdf"]; got != string(content) {
+		t.Errorf("attachment decoded to %d bytes, want %d", len(got), len(content))
+	}
+}
+
+func TestWriteQuotedPrintableLongLines(t *testing.T) {
+	text := strings.Repeat("word ", 100) + "= done"
+	var b bytes.Buffer
+	if err := Write(&b, []Message{{ID: "1", Text: text}}); err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range strings.Split(b.String(), "\n") {
+		if len(line) > 76 && !strings.HasPrefix(line, "Content-Type") {
+			t.Errorf("line of %d characters", len(line))
+		}
+	}
+	read := readMbox(t, b.String())[0]
+	if got := parts(t, read.Header.Get("Content-Type"), read.Body)["text/plain"]; got != text {
+		t.Errorf("decoded %q, want %q", got, text)
+	}
+}
+
+func TestWriteUsesUnixLineEndings(t *testing.T) {
+	attachment := filepath.Join(t.TempDir(), "notes.txt")
+	if err := os.WriteFile(attachment, bytes.Repeat([]byte("notes "), 100), 0644); err != nil {
+		t.Fatal(err)
+	}
+	var b bytes.Buffer
+	messages := []Message{
+		{ID: "1", Subject: "First", Text: "line one\nline two\n", Html: "<p>one</p>", Attachments: []string{attachment}},
+		{ID: "2", Subject: "Second", Text: strings.Repeat("long ", 40)},
+	}
+	if err := Write(&b, messages); err != nil {
+		t.Fatal(err)
+	}
+	if i := strings.Index(b.String(), "\r"); i >= 0 {
+		t.Errorf("mailbox has a carriage return at %d: %q", i, b.String()[max(0, i-20):min(b.Len(), i+20)])
+	}
+	read := readMbox(t, b.String())
+	if len(read) != 2 {
+		t.Fatalf("read %d messages, want 2", len(read))
+	}
+	if got := parts(t, read[0].Header.Get("Content-Type"), read[0].Body)["text/plain"]; got != messages[0].Text {
+		t.Errorf("decoded %q, want %q", got, messages[0].Text)
+	}
+}
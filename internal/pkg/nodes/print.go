@@ -2,30 +2,51 @@ package nodes
 
 import (
 	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
 )
 
-func recursivePrintNode(node *DirectoryNode, depth int) {
-	if node == nil {
+// PrintTree prints the folders and files under node, with each file followed by its label.
+// Files without a label, and folders without any labelled files, are left out.
+func PrintTree(w io.Writer, node *DirectoryNode, label func(file *FileNode) string) {
+	recursivePrintNode(w, node, 0, label)
+}
+
+func hasLabelledFiles(node *DirectoryNode, label func(file *FileNode) string) bool {
+	for j := range node.FileNodes {
+		if node.FileNodes[j] != nil && label(node.FileNodes[j]) != "" {
+			return true
+		}
+	}
+	for d := range node.FolderNodes {
+		if node.FolderNodes[d] != nil && hasLabelledFiles(node.FolderNodes[d], label) {
+			return true
+		}
+	}
+	return false
+}
+
+func recursivePrintNode(w io.Writer, node *DirectoryNode, depth int, label func(file *FileNode) string) {
+	if node == nil || !hasLabelledFiles(node, label) {
 		return
 	}
-	dirPrint := "\n"
-	for i := 0; i < depth; i++ {
-		dirPrint += " "
+	name := filepath.Base(node.Directory)
+	if depth == 0 {
+		name = node.Directory
 	}
-	dirPrint += fmt.Sprintf("%s", node.Directory)
-	fmt.Printf(dirPrint)
+	fmt.Fprintf(w, "%s%s/\n", strings.Repeat("  ", depth), name)
 	for j := range node.FileNodes {
 		if node.FileNodes[j] == nil {
 			continue
 		}
-		filePrint := "\n"
-		for k := 0; k < depth+1; k++ {
-			filePrint += " "
+		fileLabel := label(node.FileNodes[j])
+		if fileLabel == "" {
+			continue
 		}
-		filePrint += fmt.Sprintf("%s", node.FileNodes[j].Directory)
-		fmt.Printf(filePrint)
+		fmt.Fprintf(w, "%s%s %s\n", strings.Repeat("  ", depth+1), filepath.Base(node.FileNodes[j].Directory), fileLabel)
 	}
 	for d := range node.FolderNodes {
-		recursivePrintNode(node.FolderNodes[d], depth+1)
+		recursivePrintNode(w, node.FolderNodes[d], depth+1, label)
 	}
 }
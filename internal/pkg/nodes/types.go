@@ -2,38 +2,62 @@ package nodes
 
 import "time"
 
+type TermNode struct {
+	ID      int        `json:"id"`
+	Name    string     `json:"name"`
+	StartAt *time.Time `json:"start_at"`
+	EndAt   *time.Time `json:"end_at"`
+}
+
 type CourseNode struct {
-	ID                               int    `json:"id"`
-	Name                             string `json:"name"`
-	CourseCode                       string `json:"course_code"`
-	RestrictEnrollmentsToCourseDates bool   `json:"restrict_enrollments_to_course_dates"`
+	ID int `json:"id"`
+	// the user's nickname for the course if set, otherwise the course name
+	Name string `json:"name"`
+	// the course name if the user has set a nickname
+	OriginalName string `json:"original_name"`
+	// course code sanitised for use as a directory name
+	CourseCode string `json:"course_code"`
+	// course code as returned by canvas e.g. "CS2030/CS2030S"
+	OriginalCourseCode               string    `json:"-"`
+	RestrictEnrollmentsToCourseDates bool      `json:"restrict_enrollments_to_course_dates"`
+	Term                             *TermNode `json:"term"`
 	RootDirectory                    *DirectoryNode
 }
 
 type FileNode struct {
-	Directory    string
-	Display_name string      `json:"display_name"`
-	UpdatedAt    time.Time   `json:"updated_at"`
-	ContentType  string      `json:"content-type"`
-	Url          string      `json:"url"`
-	Locked       bool        `json:"locked"`
-	ModifiedAt   time.Ticker `json:"modified_at"`
+	Directory     string
+	CanvasPath    string
+	ID            int         `json:"id"`
+	FolderID      int         `json:"folder_id"`
+	Size          int64       `json:"size"`
+	Display_name  string      `json:"display_name"`
+	UpdatedAt     time.Time   `json:"updated_at"`
+	ContentType   string      `json:"content-type"`
+	Url           string      `json:"url"`
+	Locked        bool        `json:"locked"`
+	ModifiedAt    time.Ticker `json:"modified_at"`
+	LockedForUser bool        `json:"locked_for_user"`
+	HiddenForUser bool        `json:"hidden_for_user"`
+	UnlockAt      *time.Time  `json:"unlock_at"`
 }
 
 type DirectoryNode struct {
-	Directory     string
-	ID            int       `json:"id"`
-	Name          string    `json:"name"`
-	Updated_at    time.Time `json:"updated_at"`
-	Locked        bool      `json:"locked"`
-	FoldersUrl    string    `json:"folders_url"`
-	FoldersCount  int       `json:"folders_count"`
-	FilesUrl      string    `json:"files_url"`
-	FilesCount    int       `json:"files_count"`
-	LockedForUser bool      `json:"locked_for_user"`
-	HiddenForUser bool      `json:"hidden_for_user"`
-	FolderNodes   []*DirectoryNode
-	FileNodes     []*FileNode
+	Directory      string
+	ID             int        `json:"id"`
+	ParentFolderID int        `json:"parent_folder_id"`
+	Name           string     `json:"name"`
+	FullName       string     `json:"full_name"`
+	Updated_at     time.Time  `json:"updated_at"`
+	Locked         bool       `json:"locked"`
+	FoldersUrl     string     `json:"folders_url"`
+	FoldersCount   int        `json:"folders_count"`
+	FilesUrl       string     `json:"files_url"`
+	FilesCount     int        `json:"files_count"`
+	LockedForUser  bool       `json:"locked_for_user"`
+	HiddenForUser  bool       `json:"hidden_for_user"`
+	UnlockAt       *time.Time `json:"unlock_at"`
+	FolderNodes    []*DirectoryNode
+	FileNodes      []*FileNode
 }
 
 type BasePlannableNode struct {
@@ -92,8 +116,165 @@ type PersonNode struct {
 }
 
 type AnnouncementNode struct {
-	Title      string    `json:"title"`
-	PostedAt   time.Time `json:"posted_at"`
-	PosterName string    `json:"user_name"`
-	Message    string    `json:"message"`
+	ID          int         `json:"id"`
+	Title       string      `json:"title"`
+	PostedAt    time.Time   `json:"posted_at"`
+	PosterName  string      `json:"user_name"`
+	Message     string      `json:"message"`
+	HtmlUrl     string      `json:"html_url"`
+	Attachments []*FileNode `json:"attachments"`
+}
+
+type ModuleItemContentDetails struct {
+	LockedForUser bool       `json:"locked_for_user"`
+	UnlockAt      *time.Time `json:"unlock_at"`
+}
+
+type ModuleItemNode struct {
+	ID       int    `json:"id"`
+	Title    string `json:"title"`
+	Position int    `json:"position"`
+	Indent   int    `json:"indent"`
+	// File, Page, Discussion, Assignment, Quiz, SubHeader, ExternalUrl or ExternalTool
+	Type           string                   `json:"type"`
+	ContentID      int                      `json:"content_id"`
+	HtmlUrl        string                   `json:"html_url"`
+	Url            string                   `json:"url"`
+	ExternalUrl    string                   `json:"external_url"`
+	PageUrl        string                   `json:"page_url"`
+	ContentDetails ModuleItemContentDetails `json:"content_details"`
+}
+
+type ModuleNode struct {
+	ID         int              `json:"id"`
+	Name       string           `json:"name"`
+	Position   int              `json:"position"`
+	UnlockAt   *time.Time       `json:"unlock_at"`
+	ItemsCount int              `json:"items_count"`
+	ItemsUrl   string           `json:"items_url"`
+	Items      []ModuleItemNode `json:"items"`
+}
+
+type LockInfo struct {
+	UnlockAt *time.Time `json:"unlock_at"`
+}
+
+type PageNode struct {
+	PageID int `json:"page_id"`
+	// unique slug of the page within its course
+	Url           string    `json:"url"`
+	Title         string    `json:"title"`
+	HtmlUrl       string    `json:"html_url"`
+	UpdatedAt     time.Time `json:"updated_at"`
+	FrontPage     bool      `json:"front_page"`
+	LockedForUser bool      `json:"locked_for_user"`
+	LockInfo      *LockInfo `json:"lock_info"`
+	// only returned when fetching a single page
+	Body string `json:"body"`
+}
+
+type RubricRatingNode struct {
+	ID              string  `json:"id"`
+	Description     string  `json:"description"`
+	LongDescription string  `json:"long_description"`
+	Points          float64 `json:"points"`
+}
+
+type RubricCriterionNode struct {
+	ID              string             `json:"id"`
+	Description     string             `json:"description"`
+	LongDescription string             `json:"long_description"`
+	Points          float64            `json:"points"`
+	Ratings         []RubricRatingNode `json:"ratings"`
+}
+
+type RubricAssessmentNode struct {
+	Points   *float64 `json:"points"`
+	RatingID string   `json:"rating_id"`
+	Comments string   `json:"comments"`
+}
+
+type SubmissionCommentNode struct {
+	ID          int         `json:"id"`
+	AuthorName  string      `json:"author_name"`
+	Comment     string      `json:"comment"`
+	CreatedAt   time.Time   `json:"created_at"`
+	Attachments []*FileNode `json:"attachments"`
+}
+
+type SubmissionNode struct {
+	ID            int        `json:"id"`
+	Attempt       int        `json:"attempt"`
+	SubmittedAt   *time.Time `json:"submitted_at"`
+	Score         *float64   `json:"score"`
+	Grade         string     `json:"grade"`
+	WorkflowState string     `json:"workflow_state"`
+	Late          bool       `json:"late"`
+	Missing       bool       `json:"missing"`
+	// text entry submissions
+	Body string `json:"body"`
+	// url submissions
+	Url                string                          `json:"url"`
+	Attachments        []*FileNode                     `json:"attachments"`
+	SubmissionComments []SubmissionCommentNode         `json:"submission_comments"`
+	RubricAssessment   map[string]RubricAssessmentNode `json:"rubric_assessment"`
+}
+
+type AssignmentNode struct {
+	ID              int                   `json:"id"`
+	Name            string                `json:"name"`
+	Description     string                `json:"description"`
+	HtmlUrl         string                `json:"html_url"`
+	DueAt           *time.Time            `json:"due_at"`
+	UnlockAt        *time.Time            `json:"unlock_at"`
+	LockAt          *time.Time            `json:"lock_at"`
+	UpdatedAt       time.Time             `json:"updated_at"`
+	PointsPossible  float64               `json:"points_possible"`
+	GradingType     string                `json:"grading_type"`
+	SubmissionTypes []string              `json:"submission_types"`
+	LockedForUser   bool                  `json:"locked_for_user"`
+	LockInfo        *LockInfo             `json:"lock_info"`
+	Rubric          []RubricCriterionNode `json:"rubric"`
+	Submission      *SubmissionNode       `json:"submission"`
+}
+
+type DiscussionTopicNode struct {
+	ID            int         `json:"id"`
+	Title         string      `json:"title"`
+	Message       string      `json:"message"`
+	HtmlUrl       string      `json:"html_url"`
+	PostedAt      *time.Time  `json:"posted_at"`
+	LastReplyAt   *time.Time  `json:"last_reply_at"`
+	UserName      string      `json:"user_name"`
+	Attachments   []*FileNode `json:"attachments"`
+	LockedForUser bool        `json:"locked_for_user"`
+	LockInfo      *LockInfo   `json:"lock_info"`
+	ReplyCount    int         `json:"discussion_subentry_count"`
+}
+
+type DiscussionParticipantNode struct {
+	ID          int    `json:"id"`
+	DisplayName string `json:"display_name"`
+}
+
+type DiscussionEntryNode struct {
+	ID     int `json:"id"`
+	UserID int `json:"user_id"`
+	// entry this one replies to, nil for top level entries
+	ParentID  *int      `json:"parent_id"`
+	CreatedAt time.Time `json:"created_at"`
+	Message   string    `json:"message"`
+	Deleted   bool      `json:"deleted"`
+	// entries have a single attachment, some canvas versions return a list
+	Attachment  *FileNode             `json:"attachment"`
+	Attachments []*FileNode           `json:"attachments"`
+	Replies     []DiscussionEntryNode `json:"replies"`
+}
+
+// DiscussionViewNode is a discussion topic's full threaded replies
+type DiscussionViewNode struct {
+	Participants []DiscussionParticipantNode `json:"participants"`
+	View         []DiscussionEntryNode       `json:"view"`
+	// entries posted since canvas cached the view, flat with their parent_id
+	NewEntries []DiscussionEntryNode `json:"new_entries"`
 }
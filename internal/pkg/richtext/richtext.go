@@ -0,0 +1,351 @@
+package richtext
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// attributes holding links that are rewritten and collected
+var linkAttributes = map[string]string{
+	"a":      "href",
+	"img":    "src",
+	"iframe": "src",
+	"source": "src",
+	"video":  "src",
+	"audio":  "src",
+	"embed":  "src",
+}
+
+var (
+	whitespace    = regexp.MustCompile(`\s+`)
+	blankLines    = regexp.MustCompile(`\n{3,}`)
+	paragraphs    = regexp.MustCompile(`\n\n+`)
+	markdownChars = strings.NewReplacer(`\`, `\\`, "*", `\*`, "_", `\_`, "`", "\\`", "[", `\[`, "]", `\]`)
+	// text starting a line with these would become a heading, quote, list item or rule
+	leadingMarker  = regexp.MustCompile(`^(\s*)([#>+-])`)
+	leadingOrdered = regexp.MustCompile(`^(\s*\d+)([.)])`)
+)
+
+func parse(body string) ([]*html.Node, error) {
+	return html.ParseFragment(strings.NewReader(body), &html.Node{Type: html.ElementNode, Data: "body", DataAtom: atom.Body})
+}
+
+func walk(n *html.Node, visit func(n *html.Node)) {
+	visit(n)
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		walk(child, visit)
+	}
+}
+
+func getAttr(n *html.Node, key string) string {
+	for _, attr := range n.Attr {
+		if attr.Key == key {
+			return attr.Val
+		}
+	}
+	return ""
+}
+
+// Links returns every link (anchors, images, embeds) in an html body, in document order
+func Links(body string) ([]string, error) {
+	fragment, err := parse(body)
+	if err != nil {
+		return nil, err
+	}
+	links := make([]string, 0)
+	for _, n := range fragment {
+		walk(n, func(n *html.Node) {
+			if attr, ok := linkAttributes[n.Data]; ok && n.Type == html.ElementNode {
+				if link := strings.TrimSpace(getAttr(n, attr)); link != "" {
+					links = append(links, link)
+				}
+			}
+		})
+	}
+	return links, nil
+}
+
+// RewriteLinks returns the html body with every link replaced by rewrite(link)
+func RewriteLinks(body string, rewrite func(link string) string) (string, error) {
+	fragment, err := parse(body)
+	if err != nil {
+		return "", err
+	}
+	var b bytes.Buffer
+	for _, n := range fragment {
+		walk(n, func(n *html.Node) {
+			attr, ok := linkAttributes[n.Data]
+			if !ok || n.Type != html.ElementNode {
+				return
+			}
+			for i := range n.Attr {
+				if n.Attr[i].Key == attr && strings.TrimSpace(n.Attr[i].Val) != "" {
+					n.Attr[i].Val = rewrite(strings.TrimSpace(n.Attr[i].Val))
+				}
+			}
+		})
+		if err := html.Render(&b, n); err != nil {
+			return "", err
+		}
+	}
+	return b.String(), nil
+}
+
+// Document wraps an html body into a standalone page
+func Document(title string, body string) string {
+	title = html.EscapeString(title)
+	return fmt.Sprintf("<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n<title>%s</title>\n</head>\n<body>\n<h1>%s</h1>\n%s\n</body>\n</html>\n", title, title, body)
+}
+
+// ToMarkdown converts an html body to markdown, with every link replaced by rewrite(link) if it isn't nil
+func ToMarkdown(body string, rewrite func(link string) string) (string, error) {
+	fragment, err := parse(body)
+	if err != nil {
+		return "", err
+	}
+	c := converter{rewrite: rewrite}
+	var b strings.Builder
+	for _, n := range fragment {
+		b.WriteString(c.render(n))
+	}
+	return strings.TrimSpace(blankLines.ReplaceAllString(b.String(), "\n\n")) + "\n", nil
+}
+
+type converter struct {
+	rewrite func(link string) string
+	// lineBreak replaces <br> inside elements rendered on a single line, empty outside of them
+	lineBreak string
+}
+
+func (c converter) link(n *html.Node, attr string) string {
+	link := strings.TrimSpace(getAttr(n, attr))
+	if link == "" || c.rewrite == nil {
+		return link
+	}
+	return c.rewrite(link)
+}
+
+func (c converter) children(n *html.Node) string {
+	var b strings.Builder
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		b.WriteString(c.render(child))
+	}
+	return b.String()
+}
+
+// inline renders the children of n on a single line, with every <br> replaced by lineBreak
+func (c converter) inline(n *html.Node, lineBreak string) string {
+	c.lineBreak = lineBreak
+	return strings.TrimSpace(whitespace.ReplaceAllString(c.children(n), " "))
+}
+
+// escapeText escapes the markdown in a text node, including markers that only count at the start of a line since
+// the node could end up starting one
+func escapeText(text string) string {
+	text = markdownChars.Replace(whitespace.ReplaceAllString(text, " "))
+	text = leadingMarker.ReplaceAllString(text, `$1\$2`)
+	return leadingOrdered.ReplaceAllString(text, `$1\$2`)
+}
+
+func block(content string) string {
+	return "\n\n" + strings.TrimSpace(content) + "\n\n"
+}
+
+func wrap(content string, marker string) string {
+	trimmed := strings.TrimSpace(content)
+	if trimmed == "" {
+		return content
+	}
+	// keep the surrounding spaces outside the markers, markdown doesn't allow "** bold**"
+	leading := content[:len(content)-len(strings.TrimLeft(content, " \n\t"))]
+	trailing := content[len(strings.TrimRight(content, " \n\t")):]
+	return leading + marker + trimmed + marker + trailing
+}
+
+// prefixLines prefixes the first line of content with first and every other line with rest
+func prefixLines(content string, first string, rest string) string {
+	lines := strings.Split(content, "\n")
+	for i := range lines {
+		prefix := rest
+		if i == 0 {
+			prefix = first
+		}
+		if strings.TrimSpace(lines[i]) == "" {
+			lines[i] = strings.TrimRight(prefix, " ")
+			continue
+		}
+		lines[i] = prefix + lines[i]
+	}
+	return strings.Join(lines, "\n")
+}
+
+func textContent(n *html.Node) string {
+	var b strings.Builder
+	walk(n, func(n *html.Node) {
+		if n.Type == html.TextNode {
+			b.WriteString(n.Data)
+		} else if n.Type == html.ElementNode && n.Data == "br" {
+			b.WriteString("\n")
+		}
+	})
+	return b.String()
+}
+
+func (c converter) render(n *html.Node) string {
+	switch n.Type {
+	case html.TextNode:
+		return escapeText(n.Data)
+	case html.ElementNode:
+	default:
+		return c.children(n)
+	}
+	switch n.Data {
+	case "script", "style", "head", "noscript", "title":
+		return ""
+	case "h1", "h2", "h3", "h4", "h5", "h6":
+		level := int(n.Data[1] - '0')
+		// headings can't span lines
+		return block(strings.Repeat("#", level) + " " + c.inline(n, " "))
+	case "p", "div", "section", "article", "header", "footer", "figure", "figcaption", "main", "aside", "details", "summary":
+		return block(c.children(n))
+	case "br":
+		if c.lineBreak != "" {
+			return c.lineBreak
+		}
+		return "  \n"
+	case "hr":
+		return block("---")
+	case "strong", "b":
+		return wrap(c.children(n), "**")
+	case "em", "i":
+		return wrap(c.children(n), "_")
+	case "del", "s", "strike":
+		return wrap(c.children(n), "~~")
+	case "code", "kbd", "samp":
+		code := textContent(n)
+		if strings.TrimSpace(code) == "" {
+			return code
+		}
+		if strings.Contains(code, "`") {
+			return "`` " + code + " ``"
+		}
+		return "`" + code + "`"
+	case "pre":
+		code := strings.Trim(textContent(n), "\n")
+		fence := "```"
+		for strings.Contains(code, fence) {
+			fence += "`"
+		}
+		return "\n\n" + fence + "\n" + code + "\n" + fence + "\n\n"
+	case "a":
+		href := c.link(n, "href")
+		text := c.inline(n, "<br>")
+		if href == "" {
+			return text
+		}
+		if text == "" {
+			text = markdownChars.Replace(href)
+		}
+		return fmt.Sprintf("[%s](<%s>)", text, href)
+	case "img":
+		src := c.link(n, "src")
+		if src == "" {
+			return ""
+		}
+		return fmt.Sprintf("![%s](<%s>)", markdownChars.Replace(getAttr(n, "alt")), src)
+	case "iframe", "video", "audio", "embed":
+		src := c.link(n, "src")
+		if src == "" {
+			return c.children(n)
+		}
+		title := getAttr(n, "title")
+		if title == "" {
+			title = src
+		}
+		return block(fmt.Sprintf("[%s](<%s>)", markdownChars.Replace(title), src))
+	case "ul", "ol":
+		return block(c.list(n))
+	case "blockquote":
+		return block(prefixLines(strings.TrimSpace(blankLines.ReplaceAllString(c.children(n), "\n\n")), "> ", "> "))
+	case "table":
+		return block(c.table(n))
+	}
+	return c.children(n)
+}
+
+func (c converter) list(n *html.Node) string {
+	items := make([]string, 0)
+	number := 1
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		if child.Type != html.ElementNode || child.Data != "li" {
+			continue
+		}
+		marker := "- "
+		if n.Data == "ol" {
+			marker = fmt.Sprintf("%d. ", number)
+			number++
+		}
+		// list items are kept tight, paragraphs and nested lists only start a new line
+		content := paragraphs.ReplaceAllString(strings.TrimSpace(c.children(child)), "\n")
+		items = append(items, prefixLines(content, marker, strings.Repeat(" ", len(marker))))
+	}
+	return strings.Join(items, "\n")
+}
+
+// rows returns the rows of a table, skipping the rows of tables nested in its cells
+func rows(table *html.Node) []*html.Node {
+	rows := make([]*html.Node, 0)
+	for child := table.FirstChild; child != nil; child = child.NextSibling {
+		if child.Type != html.ElementNode {
+			continue
+		}
+		switch child.Data {
+		case "tr":
+			rows = append(rows, child)
+		case "thead", "tbody", "tfoot":
+			for row := child.FirstChild; row != nil; row = row.NextSibling {
+				if row.Type == html.ElementNode && row.Data == "tr" {
+					rows = append(rows, row)
+				}
+			}
+		}
+	}
+	return rows
+}
+
+func (c converter) table(n *html.Node) string {
+	cells := make([][]string, 0)
+	for _, row := range rows(n) {
+		rowCells := make([]string, 0)
+		for cell := row.FirstChild; cell != nil; cell = cell.NextSibling {
+			if cell.Type == html.ElementNode && (cell.Data == "td" || cell.Data == "th") {
+				// a cell is a single line, line breaks are kept as html
+				rowCells = append(rowCells, strings.ReplaceAll(c.inline(cell, "<br>"), "|", `\|`))
+			}
+		}
+		cells = append(cells, rowCells)
+	}
+	columns := 0
+	for _, row := range cells {
+		columns = max(columns, len(row))
+	}
+	if columns == 0 {
+		return ""
+	}
+	var b strings.Builder
+	for i, row := range cells {
+		for len(row) < columns {
+			row = append(row, "")
+		}
+		b.WriteString("| " + strings.Join(row, " | ") + " |\n")
+		if i == 0 {
+			b.WriteString(strings.Repeat("| --- ", columns) + "|\n")
+		}
+	}
+	return b.String()
+}
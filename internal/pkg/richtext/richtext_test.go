@@ -0,0 +1,130 @@
+package richtext
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestToMarkdown(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{"paragraphs", "<p>one</p><p>two</p>", "one\n\ntwo"},
+		{"emphasis", "<p><strong>bold </strong>and <em>italic</em></p>", "**bold** and _italic_"},
+		{"escaped characters", "<p>a*b_c [d]</p>", `a\*b\_c \[d\]`},
+		{"heading", "<h2>Week 1</h2>", "## Week 1"},
+		{"line break in heading", "<h2>Week 1<br>Intro</h2>", "## Week 1 Intro"},
+		{"line break", "<p>one<br>two</p>", "one  \ntwo"},
+		{"leading heading marker", "<p># not a heading</p>", `\# not a heading`},
+		{"leading quote marker", "<p>&gt; not a quote</p>", `\> not a quote`},
+		{"leading list markers", "<p>+ one</p><p>- two</p>", "\\+ one\n\n\\- two"},
+		{"leading number", "<p>1. not a list</p>", `1\. not a list`},
+		{"leading marker after a line break", "<p>one<br># two</p>", "one  \n\\# two"},
+		{"unordered list", "<ul><li>one</li><li>two</li></ul>", "- one\n- two"},
+		{"ordered list", "<ol><li>one</li><li>two</li></ol>", "1. one\n2. two"},
+		{"nested list", "<ul><li>one<ul><li>inner</li></ul></li><li>two</li></ul>", "- one\n  - inner\n- two"},
+		{"list item paragraphs", "<ol><li><p>one</p><p>more</p></li></ol>", "1. one\n   more"},
+		{"inline code", "<p>run <code>go test *</code></p>", "run `go test *`"},
+		{"inline code with backticks", "<p><code>a`b</code></p>", "`` a`b ``"},
+		{"preformatted", "<pre><code>if x {\n\treturn\n}</code></pre>", "```\nif x {\n\treturn\n}\n```"},
+		{"preformatted with fences", "<pre>```</pre>", "````\n```\n````"},
+		{"blockquote", "<blockquote><p>one</p><p>two</p></blockquote>", "> one\n>\n> two"},
+		{"link", `<a href="https://example.com/a">the <b>site</b></a>`, "[the **site**](<https://example.com/a>)"},
+		{"link without text", `<a href="https://example.com/a_b"></a>`, `[https://example.com/a\_b](<https://example.com/a_b>)`},
+		{"line break in link", `<a href="a.pdf">one<br>two</a>`, "[one<br>two](<a.pdf>)"},
+		{"image", `<img src="a.png" alt="a_diagram">`, `![a\_diagram](<a.png>)`},
+		{"embed", `<iframe src="https://example.com/video" title="Lecture"></iframe>`, "[Lecture](<https://example.com/video>)"},
+		{"scripts are dropped", "<p>text</p><script>alert(1)</script>", "text"},
+		{
+			"table",
+			"<table><thead><tr><th>a</th><th>b</th></tr></thead><tbody><tr><td>1</td><td>x|y</td></tr><tr><td>2</td></tr></tbody></table>",
+			"| a | b |\n| --- | --- |\n| 1 | x\\|y |\n| 2 |  |",
+		},
+		{"line break in table cell", "<table><tr><td>one<br>two</td></tr></table>", "| one<br>two |\n| --- |"},
+		{
+			"nested table",
+			"<table><tr><td>outer</td><td><table><tr><td>inner</td></tr></table></td></tr><tr><td>last</td></tr></table>",
+			"| outer | \\| inner \\| \\| --- \\| |\n| --- | --- |\n| last |  |",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ToMarkdown(tt.body, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want+"\n" {
+				t.Errorf("got\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestToMarkdownRewritesLinks(t *testing.T) {
+	body := `<p><a href="/files/1">notes</a> <img src="/files/2"></p>`
+	got, err := ToMarkdown(body, func(link string) string {
+		return "local" + link
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "[notes](<local/files/1>) ![](<local/files/2>)\n"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestLinks(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want []string
+	}{
+		{"none", "<p>text</p>", []string{}},
+		{"in document order", `<a href="a">a</a><p><img src="b"><video src="c"></video></p>`, []string{"a", "b", "c"}},
+		{"trimmed and blank skipped", `<a href=" a ">a</a><a href="">b</a><a>c</a>`, []string{"a"}},
+		{"nested", `<ul><li><a href="a">a</a><ul><li><iframe src="b"></iframe></li></ul></li></ul>`, []string{"a", "b"}},
+		{"attributes of other elements ignored", `<div src="a"><a src="b">b</a></div>`, []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Links(tt.body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRewriteLinks(t *testing.T) {
+	rewrite := func(link string) string {
+		return strings.ToUpper(link)
+	}
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{"anchor", `<a href=" a ">text</a>`, `<a href="A">text</a>`},
+		{"image", `<p><img src="b" alt="b"/></p>`, `<p><img src="B" alt="b"/></p>`},
+		{"other attributes kept", `<a class="x" href="a" title="t">t</a>`, `<a class="x" href="A" title="t">t</a>`},
+		{"blank kept", `<a href="">t</a>`, `<a href="">t</a>`},
+		{"text untouched", `<p>a <b>b</b></p>`, `<p>a <b>b</b></p>`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := RewriteLinks(tt.body, rewrite)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,228 @@
+package site
+
+import (
+	"html/template"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const INDEX_FILE = "index.html"
+
+// Layout is where the pull commands save each kind of content in a course directory
+type Layout struct {
+	SyllabusDir            string
+	SyllabusDocument       string
+	SyllabusAttachmentsDir string
+	AnnouncementsDir       string
+	AnnouncementDocument   string
+	ModulesDir             string
+	PagesDir               string
+	AssignmentsDir         string
+	AssignmentDocument     string
+	DiscussionsDir         string
+	DiscussionDocument     string
+	FilesDir               string
+	VideosDir              string
+	// suffix of partial downloads, left out of the site
+	PartSuffix string
+}
+
+// Entry is a linked item in the site's navigation, with the items nested under it
+type Entry struct {
+	Name     string
+	Link     string
+	Children []Entry
+}
+
+type Section struct {
+	ID      string
+	Name    string
+	Entries []Entry
+}
+
+type index struct {
+	Title      string
+	ExportedAt string
+	Sections   []Section
+}
+
+var indexTemplate = template.Must(template.New("index").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Title}}</title>
+<style>
+body { margin: 0; font-family: -apple-system, BlinkMacSystemFont, "Segoe UI", Helvetica, Arial, sans-serif; color: #222; }
+nav { position: fixed; top: 0; bottom: 0; left: 0; width: 14rem; padding: 1rem; overflow-y: auto; background: #f4f4f4; border-right: 1px solid #ddd; box-sizing: border-box; }
+nav a { display: block; padding: 0.25rem 0; color: #0374b5; text-decoration: none; }
+main { margin-left: 14rem; padding: 1rem 2rem; max-width: 60rem; }
+section { margin-bottom: 2rem; }
+ul { padding-left: 1.25rem; }
+li { margin: 0.2rem 0; }
+a { color: #0374b5; }
+.exported { color: #666; }
+</style>
+</head>
+<body>
+<nav>
+<strong>{{.Title}}</strong>
+{{range .Sections}}<a href="#{{.ID}}">{{.Name}}</a>
+{{end}}</nav>
+<main>
+<h1>{{.Title}}</h1>
+<p class="exported">Exported {{.ExportedAt}} with canvas-sync</p>
+{{range .Sections}}<section id="{{.ID}}">
+<h2>{{.Name}}</h2>
+{{template "entries" .Entries}}
+</section>
+{{end}}</main>
+</body>
+</html>
+{{define "entries"}}<ul>
+{{range .}}<li>{{if .Link}}<a href="{{.Link}}">{{.Name}}</a>{{else}}{{.Name}}{{end}}{{if .Children}}
+{{template "entries" .Children}}{{end}}</li>
+{{end}}</ul>{{end}}
+`))
+
+// link returns the url of path relative to root, escaping each segment
+func link(root string, path string) string {
+	rel, err := filepath.Rel(root, path)
+	if err != nil {
+		return ""
+	}
+	segments := strings.Split(filepath.ToSlash(rel), "/")
+	for i := range segments {
+		segments[i] = url.PathEscape(segments[i])
+	}
+	return strings.Join(segments, "/")
+}
+
+// skipped reports whether a local file isn't part of the course's content (sync state, trash, partial downloads)
+func (l Layout) skipped(name string) bool {
+	return strings.HasPrefix(name, ".") || (l.PartSuffix != "" && strings.HasSuffix(name, l.PartSuffix)) || strings.HasSuffix(name, ".tmp")
+}
+
+func (l Layout) readDir(dir string) []os.DirEntry {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+	kept := make([]os.DirEntry, 0, len(entries))
+	for _, entry := range entries {
+		if !l.skipped(entry.Name()) {
+			kept = append(kept, entry)
+		}
+	}
+	return kept
+}
+
+// tree lists everything under dir, folders first
+func (l Layout) tree(root string, dir string) []Entry {
+	folders := make([]Entry, 0)
+	files := make([]Entry, 0)
+	for _, entry := range l.readDir(dir) {
+		path := filepath.Join(dir, entry.Name())
+		if entry.IsDir() {
+			if children := l.tree(root, path); len(children) > 0 {
+				folders = append(folders, Entry{Name: entry.Name() + "/", Children: children})
+			}
+			continue
+		}
+		files = append(files, Entry{Name: entry.Name(), Link: link(root, path)})
+	}
+	return append(folders, files...)
+}
+
+// documents lists the folders under dir holding a <document>.html (assignments, discussions, ...), each linking to
+// its document with the rest of the folder (attachments, submissions) nested under it
+func (l Layout) documents(root string, dir string, document string) []Entry {
+	entries := make([]Entry, 0)
+	for _, entry := range l.readDir(dir) {
+		path := filepath.Join(dir, entry.Name())
+		documentPath := filepath.Join(path, document+".html")
+		if !entry.IsDir() {
+			continue
+		}
+		if _, err := os.Stat(documentPath); err != nil {
+			continue
+		}
+		children := make([]Entry, 0)
+		for _, child := range l.tree(root, path) {
+			if child.Name != document+".html" && child.Name != document+".md" {
+				children = append(children, child)
+			}
+		}
+		entries = append(entries, Entry{Name: entry.Name(), Link: link(root, documentPath), Children: children})
+	}
+	return entries
+}
+
+// htmlFiles lists the html files directly in dir, named without their extension
+func (l Layout) htmlFiles(root string, dir string) []Entry {
+	entries := make([]Entry, 0)
+	for _, entry := range l.readDir(dir) {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".html" {
+			continue
+		}
+		entries = append(entries, Entry{Name: strings.TrimSuffix(entry.Name(), ".html"), Link: link(root, filepath.Join(dir, entry.Name()))})
+	}
+	return entries
+}
+
+// Sections lists the content downloaded into a course directory by the pull commands, in the order they're shown
+func Sections(courseDir string, layout Layout) []Section {
+	announcements := layout.documents(courseDir, filepath.Join(courseDir, layout.AnnouncementsDir), layout.AnnouncementDocument)
+	// folders start with the date they were posted, newest first
+	sort.SliceStable(announcements, func(i, j int) bool {
+		return announcements[i].Name > announcements[j].Name
+	})
+	syllabusDir := filepath.Join(courseDir, layout.SyllabusDir)
+	syllabusPath := filepath.Join(syllabusDir, layout.SyllabusDocument+".html")
+	syllabus := make([]Entry, 0)
+	if _, err := os.Stat(syllabusPath); err == nil {
+		syllabus = append(syllabus, Entry{Name: "Syllabus", Link: link(courseDir, syllabusPath)})
+		syllabus = append(syllabus, layout.tree(courseDir, filepath.Join(syllabusDir, layout.SyllabusAttachmentsDir))...)
+	}
+	sections := []Section{
+		{ID: "syllabus", Name: "Syllabus", Entries: syllabus},
+		{ID: "announcements", Name: "Announcements", Entries: announcements},
+		{ID: "modules", Name: "Modules", Entries: layout.tree(courseDir, filepath.Join(courseDir, layout.ModulesDir))},
+		{ID: "pages", Name: "Pages", Entries: layout.htmlFiles(courseDir, filepath.Join(courseDir, layout.PagesDir))},
+		{ID: "assignments", Name: "Assignments", Entries: layout.documents(courseDir, filepath.Join(courseDir, layout.AssignmentsDir), layout.AssignmentDocument)},
+		{ID: "discussions", Name: "Discussions", Entries: layout.documents(courseDir, filepath.Join(courseDir, layout.DiscussionsDir), layout.DiscussionDocument)},
+		{ID: "files", Name: "Files", Entries: layout.tree(courseDir, filepath.Join(courseDir, layout.FilesDir))},
+		{ID: "videos", Name: "Videos", Entries: layout.tree(courseDir, filepath.Join(courseDir, layout.VideosDir))},
+	}
+	kept := make([]Section, 0, len(sections))
+	for _, section := range sections {
+		if len(section.Entries) > 0 {
+			kept = append(kept, section)
+		}
+	}
+	return kept
+}
+
+// Export writes an index.html into courseDir linking (relatively) to everything downloaded for the course, so the
+// directory can be browsed offline or zipped and shared. Returns the path of the index and its sections.
+func Export(courseDir string, title string, layout Layout) (string, []Section, error) {
+	sections := Sections(courseDir, layout)
+	indexPath := filepath.Join(courseDir, INDEX_FILE)
+	file, err := os.Create(indexPath)
+	if err != nil {
+		return "", nil, err
+	}
+	err = indexTemplate.Execute(file, index{
+		Title:      title,
+		ExportedAt: time.Now().Format("02 Jan 2006 03:04 PM"),
+		Sections:   sections,
+	})
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	return indexPath, sections, err
+}
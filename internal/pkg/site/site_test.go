@@ -0,0 +1,153 @@
+package site
+
+import (
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+var testLayout = Layout{
+	SyllabusDir:            "syllabus",
+	SyllabusDocument:       "syllabus",
+	SyllabusAttachmentsDir: "attachments",
+	AnnouncementsDir:       "announcements",
+	AnnouncementDocument:   "announcement",
+	ModulesDir:             "modules",
+	PagesDir:               "pages",
+	AssignmentsDir:         "assignments",
+	AssignmentDocument:     "assignment",
+	DiscussionsDir:         "discussions",
+	DiscussionDocument:     "discussion",
+	FilesDir:               "files",
+	VideosDir:              "videos",
+	PartSuffix:             ".part",
+}
+
+// courseDir creates a course directory holding the given files, relative and slash separated
+func courseDir(t *testing.T, files ...string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for _, file := range files {
+		path := filepath.Join(dir, filepath.FromSlash(file))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(file), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestSections(t *testing.T) {
+	dir := courseDir(t,
+		"syllabus/syllabus.html",
+		"syllabus/syllabus.md",
+		"syllabus/attachments/schedule.pdf",
+		"announcements/2024-03-01 Welcome/announcement.html",
+		"announcements/2024-03-01 Welcome/announcement.md",
+		"announcements/2024-03-08 Week 2/announcement.html",
+		"announcements/2024-03-08 Week 2/attachments/slides.pdf",
+		"pages/Course Info.html",
+		"pages/Course Info.md",
+		"assignments/HW 1/assignment.html",
+		"assignments/HW 1/submission/answers.pdf",
+		// no document, not an assignment
+		"assignments/notes/readme.txt",
+		"files/Week 1/lecture #1.pdf",
+		"files/syllabus.pdf",
+		"files/big.mp4.part",
+		"files/.big.mp4.part.json",
+		"files/.canvas-sync-trash/2024-03-01/files/old.pdf",
+		".canvas-sync-manifest.json",
+	)
+
+	got := Sections(dir, testLayout)
+	want := []Section{
+		{ID: "syllabus", Name: "Syllabus", Entries: []Entry{
+			{Name: "Syllabus", Link: "syllabus/syllabus.html"},
+			{Name: "schedule.pdf", Link: "syllabus/attachments/schedule.pdf"},
+		}},
+		{ID: "announcements", Name: "Announcements", Entries: []Entry{
+			{Name: "2024-03-08 Week 2", Link: "announcements/2024-03-08%20Week%202/announcement.html", Children: []Entry{
+				{Name: "attachments/", Children: []Entry{{Name: "slides.pdf", Link: "announcements/2024-03-08%20Week%202/attachments/slides.pdf"}}},
+			}},
+			{Name: "2024-03-01 Welcome", Link: "announcements/2024-03-01%20Welcome/announcement.html", Children: []Entry{}},
+		}},
+		{ID: "pages", Name: "Pages", Entries: []Entry{
+			{Name: "Course Info", Link: "pages/Course%20Info.html"},
+		}},
+		{ID: "assignments", Name: "Assignments", Entries: []Entry{
+			{Name: "HW 1", Link: "assignments/HW%201/assignment.html", Children: []Entry{
+				{Name: "submission/", Children: []Entry{{Name: "answers.pdf", Link: "assignments/HW%201/submission/answers.pdf"}}},
+			}},
+		}},
+		{ID: "files", Name: "Files", Entries: []Entry{
+			{Name: "Week 1/", Children: []Entry{{Name: "lecture #1.pdf", Link: "files/Week%201/lecture%20%231.pdf"}}},
+			{Name: "syllabus.pdf", Link: "files/syllabus.pdf"},
+		}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Sections() =\n%+v\nwant\n%+v", got, want)
+	}
+
+	if got := Sections(t.TempDir(), testLayout); len(got) != 0 {
+		t.Errorf("Sections() of an empty course = %+v, want none", got)
+	}
+}
+
+var href = regexp.MustCompile(`href="([^"]*)"`)
+
+func TestExport(t *testing.T) {
+	dir := courseDir(t,
+		"syllabus/syllabus.html",
+		"modules/Week 1/intro & overview.pdf",
+		"discussions/Introductions/discussion.html",
+		"videos/Lecture 1.mp4",
+	)
+
+	indexPath, sections, err := Export(dir, "CS1010 <Programming>", testLayout)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if indexPath != filepath.Join(dir, INDEX_FILE) {
+		t.Errorf("exported to %s, want the course directory's %s", indexPath, INDEX_FILE)
+	}
+	if len(sections) != 4 {
+		t.Errorf("exported %d sections, want syllabus, modules, discussions and videos", len(sections))
+	}
+	content, err := os.ReadFile(indexPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	index := string(content)
+	if !strings.Contains(index, "<title>CS1010 &lt;Programming&gt;</title>") {
+		t.Error("index doesn't have the escaped course title")
+	}
+
+	links := 0
+	for _, match := range href.FindAllStringSubmatch(index, -1) {
+		if strings.HasPrefix(match[1], "#") {
+			continue
+		}
+		links++
+		u, err := url.Parse(strings.ReplaceAll(match[1], "&amp;", "&"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if u.IsAbs() || strings.HasPrefix(u.Path, "/") {
+			t.Errorf("link %s isn't relative to the index", match[1])
+			continue
+		}
+		if _, err := os.Stat(filepath.Join(dir, filepath.FromSlash(u.Path))); err != nil {
+			t.Errorf("link %s doesn't resolve to a file next to the index: %v", match[1], err)
+		}
+	}
+	if links != 4 {
+		t.Errorf("index links to %d files, want 4", links)
+	}
+}
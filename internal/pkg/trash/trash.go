@@ -0,0 +1,151 @@
+package trash
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aidanaden/canvas-sync/internal/pkg/utils"
+)
+
+const (
+	TRASH_DIR   = ".canvas-sync-trash"
+	DATE_FORMAT = "2006-01-02"
+)
+
+// Item is a file moved into a course's trash
+type Item struct {
+	// date the file was trashed, as named in the trash e.g. "2024-03-01", or "2024-03-01 (2)" for the second copy of a
+	// file trashed that day
+	Date string
+	// original path relative to the course directory, slash separated
+	Path string
+	Size int64
+}
+
+// ID identifies an item within a course's trash e.g. "2024-03-01/files/Week 1/answers.pdf"
+func (i Item) ID() string {
+	return i.Date + "/" + i.Path
+}
+
+func trashDir(courseDir string) string {
+	return filepath.Join(courseDir, TRASH_DIR)
+}
+
+// Move moves a file in courseDir into today's trash, returning its new location. A file already trashed today is kept,
+// the new copy goes into a suffixed folder for the day instead
+func Move(courseDir string, localPath string, now time.Time) (string, error) {
+	rel, err := filepath.Rel(courseDir, localPath)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return "", fmt.Errorf("%s is not inside %s", localPath, courseDir)
+	}
+	date := now.Format(DATE_FORMAT)
+	dest := filepath.Join(trashDir(courseDir), date, rel)
+	for n := 2; ; n++ {
+		if _, err := os.Lstat(dest); errors.Is(err, os.ErrNotExist) {
+			break
+		}
+		dest = filepath.Join(trashDir(courseDir), fmt.Sprintf("%s (%d)", date, n), rel)
+	}
+	if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		return "", err
+	}
+	if err := os.Rename(localPath, dest); err != nil {
+		return "", err
+	}
+	return dest, nil
+}
+
+// List returns every trashed file in courseDir, most recently trashed first
+func List(courseDir string) ([]Item, error) {
+	root := trashDir(courseDir)
+	items := make([]Item, 0)
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		date, itemPath, found := strings.Cut(filepath.ToSlash(rel), "/")
+		if !found {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		items = append(items, Item{Date: date, Path: itemPath, Size: info.Size()})
+		return nil
+	})
+	if errors.Is(err, os.ErrNotExist) {
+		return items, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(items, func(i, j int) bool {
+		if items[i].Date != items[j].Date {
+			return trashedAfter(items[i].Date, items[j].Date)
+		}
+		return items[i].Path < items[j].Path
+	})
+	return items, nil
+}
+
+// trashedAfter reports whether the trash folder named a was created after b, "2024-03-01 (10)" comes after
+// "2024-03-01 (9)"
+func trashedAfter(a string, b string) bool {
+	if len(a) >= len(DATE_FORMAT) && len(b) >= len(DATE_FORMAT) && a[:len(DATE_FORMAT)] == b[:len(DATE_FORMAT)] && len(a) != len(b) {
+		return len(a) > len(b)
+	}
+	return a > b
+}
+
+// Restore moves a trashed file back to its original location, refusing to overwrite an existing file
+func Restore(courseDir string, item Item) (string, error) {
+	src := filepath.Join(trashDir(courseDir), item.Date, filepath.FromSlash(item.Path))
+	dest := filepath.Join(courseDir, filepath.FromSlash(item.Path))
+	if _, err := os.Stat(dest); err == nil {
+		return "", fmt.Errorf("%s already exists", dest)
+	}
+	if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		return "", err
+	}
+	if err := os.Rename(src, dest); err != nil {
+		return "", err
+	}
+	utils.RemoveEmptyParents(filepath.Dir(src), trashDir(courseDir))
+	return dest, nil
+}
+
+// Find returns the trashed item matching id, or the most recently trashed copy of a path
+func Find(items []Item, idOrPath string) (Item, bool) {
+	idOrPath = strings.Trim(filepath.ToSlash(idOrPath), "/")
+	for _, item := range items {
+		if item.ID() == idOrPath {
+			return item, true
+		}
+	}
+	// items are sorted newest first
+	for _, item := range items {
+		if item.Path == idOrPath {
+			return item, true
+		}
+	}
+	return Item{}, false
+}
+
+// Empty permanently deletes everything in courseDir's trash
+func Empty(courseDir string) error {
+	return os.RemoveAll(trashDir(courseDir))
+}
@@ -0,0 +1,172 @@
+package trash
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func writeFile(t *testing.T, path string, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(content)
+}
+
+func TestMove(t *testing.T) {
+	courseDir := t.TempDir()
+	localPath := filepath.Join(courseDir, "files", "Week 1", "answers.pdf")
+	writeFile(t, localPath, "v1")
+	now := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+
+	dest, err := Move(courseDir, localPath, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := filepath.Join(courseDir, TRASH_DIR, "2024-03-01", "files", "Week 1", "answers.pdf")
+	if dest != want {
+		t.Errorf("moved to %s, want %s", dest, want)
+	}
+	if _, err := os.Stat(localPath); !os.IsNotExist(err) {
+		t.Error("the trashed file was left in place")
+	}
+	if _, err := Move(courseDir, filepath.Join(t.TempDir(), "other.pdf"), now); err == nil {
+		t.Error("moved a file outside the course directory")
+	}
+}
+
+func TestMoveKeepsFilesTrashedTheSameDay(t *testing.T) {
+	courseDir := t.TempDir()
+	localPath := filepath.Join(courseDir, "files", "answers.pdf")
+	now := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	dests := make([]string, 0)
+	for _, content := range []string{"v1", "v2", "v3"} {
+		writeFile(t, localPath, content)
+		dest, err := Move(courseDir, localPath, now)
+		if err != nil {
+			t.Fatal(err)
+		}
+		dests = append(dests, dest)
+	}
+
+	for i, date := range []string{"2024-03-01", "2024-03-01 (2)", "2024-03-01 (3)"} {
+		want := filepath.Join(courseDir, TRASH_DIR, date, "files", "answers.pdf")
+		if dests[i] != want {
+			t.Errorf("copy %d moved to %s, want %s", i+1, dests[i], want)
+		}
+		if got := readFile(t, want); got != []string{"v1", "v2", "v3"}[i] {
+			t.Errorf("%s holds %q, want copy %d", want, got, i+1)
+		}
+	}
+}
+
+func TestList(t *testing.T) {
+	courseDir := t.TempDir()
+	for _, date := range []string{"2024-03-01", "2024-03-02", "2024-03-01 (2)", "2024-03-01 (10)", "2024-03-01 (9)"} {
+		writeFile(t, filepath.Join(courseDir, TRASH_DIR, date, "files", "b.pdf"), date)
+	}
+	writeFile(t, filepath.Join(courseDir, TRASH_DIR, "2024-03-02", "files", "a.pdf"), "a")
+
+	items, err := List(courseDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"2024-03-02/files/a.pdf",
+		"2024-03-02/files/b.pdf",
+		"2024-03-01 (10)/files/b.pdf",
+		"2024-03-01 (9)/files/b.pdf",
+		"2024-03-01 (2)/files/b.pdf",
+		"2024-03-01/files/b.pdf",
+	}
+	if len(items) != len(want) {
+		t.Fatalf("listed %v, want %v", items, want)
+	}
+	for i, item := range items {
+		if item.ID() != want[i] {
+			t.Errorf("item %d is %s, want %s", i, item.ID(), want[i])
+		}
+	}
+	if items[0].Size != 1 {
+		t.Errorf("size of %s is %d, want 1", items[0].ID(), items[0].Size)
+	}
+
+	empty, err := List(t.TempDir())
+	if err != nil || len(empty) != 0 {
+		t.Errorf("listed %v, %v for a course without trash, want nothing", empty, err)
+	}
+}
+
+func TestFind(t *testing.T) {
+	items := []Item{
+		{Date: "2024-03-02", Path: "files/a.pdf"},
+		{Date: "2024-03-01 (2)", Path: "files/b.pdf"},
+		{Date: "2024-03-01", Path: "files/b.pdf"},
+	}
+	tests := []struct {
+		name     string
+		idOrPath string
+		want     string
+		found    bool
+	}{
+		{"id", "2024-03-01/files/b.pdf", "2024-03-01/files/b.pdf", true},
+		{"path picks the newest copy", "files/b.pdf", "2024-03-01 (2)/files/b.pdf", true},
+		{"surrounding slashes", "/files/a.pdf/", "2024-03-02/files/a.pdf", true},
+		{"missing", "files/c.pdf", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			item, found := Find(items, tt.idOrPath)
+			if found != tt.found || (found && item.ID() != tt.want) {
+				t.Errorf("Find(%q) = %s, %v, want %s, %v", tt.idOrPath, item.ID(), found, tt.want, tt.found)
+			}
+		})
+	}
+}
+
+func TestRestore(t *testing.T) {
+	courseDir := t.TempDir()
+	localPath := filepath.Join(courseDir, "files", "Week 1", "answers.pdf")
+	now := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	for _, content := range []string{"v1", "v2"} {
+		writeFile(t, localPath, content)
+		if _, err := Move(courseDir, localPath, now); err != nil {
+			t.Fatal(err)
+		}
+	}
+	items, err := List(courseDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	item, _ := Find(items, "files/Week 1/answers.pdf")
+
+	dest, err := Restore(courseDir, item)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dest != localPath || readFile(t, localPath) != "v2" {
+		t.Errorf("restored %s to %s, want the latest copy at %s", item.ID(), dest, localPath)
+	}
+	if _, err := os.Stat(filepath.Join(courseDir, TRASH_DIR, item.Date)); !os.IsNotExist(err) {
+		t.Error("the emptied trash folder was left behind")
+	}
+	if _, err := Restore(courseDir, Item{Date: "2024-03-01", Path: "files/Week 1/answers.pdf"}); err == nil {
+		t.Error("restoring over an existing file succeeded")
+	}
+	if got := readFile(t, localPath); got != "v2" {
+		t.Errorf("the restored file was overwritten with %q", got)
+	}
+}
@@ -0,0 +1,52 @@
+package utils
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pterm/pterm"
+	"github.com/spf13/viper"
+)
+
+// CourseDir is a course's downloaded data directory
+type CourseDir struct {
+	Code string
+	Dir  string
+}
+
+// GetCourseDirs returns the downloaded course directories matching codes (case-insensitive), all of them if none are given
+func GetCourseDirs(codes []string) []CourseDir {
+	targetDir := fmt.Sprintf("%s", viper.Get("data_dir"))
+	targetDir = GetExpandedHomeDirectoryPath(targetDir)
+	entries, err := os.ReadDir(targetDir)
+	if err != nil {
+		pterm.Error.Printfln("Failed to read data directory %s: %s", targetDir, err.Error())
+		os.Exit(1)
+	}
+	wanted := make(map[string]bool)
+	for _, code := range codes {
+		wanted[strings.ToLower(code)] = true
+	}
+	dirs := make([]CourseDir, 0)
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		if len(wanted) > 0 && !wanted[strings.ToLower(entry.Name())] {
+			continue
+		}
+		delete(wanted, strings.ToLower(entry.Name()))
+		dirs = append(dirs, CourseDir{Code: entry.Name(), Dir: filepath.Join(targetDir, entry.Name())})
+	}
+	for code := range wanted {
+		pterm.Error.Printfln("No downloaded course found for %s in %s", code, targetDir)
+		os.Exit(1)
+	}
+	sort.Slice(dirs, func(i, j int) bool {
+		return dirs[i].Code < dirs[j].Code
+	})
+	return dirs
+}
@@ -0,0 +1,18 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// RemoveEmptyParents removes dir and its parents up to (excluding) root while they're empty
+func RemoveEmptyParents(dir string, root string) {
+	for dir != root && strings.HasPrefix(dir, root+string(filepath.Separator)) {
+		// fails on non-empty directories
+		if err := os.Remove(dir); err != nil {
+			return
+		}
+		dir = filepath.Dir(dir)
+	}
+}
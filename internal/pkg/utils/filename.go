@@ -0,0 +1,74 @@
+package utils
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+)
+
+// longest file name (in bytes) supported by common filesystems
+const MAX_FILENAME_LENGTH = 255
+
+// device names reserved on windows, with or without an extension
+var reservedFilenames = map[string]bool{
+	"CON": true, "PRN": true, "AUX": true, "NUL": true,
+	"COM1": true, "COM2": true, "COM3": true, "COM4": true, "COM5": true, "COM6": true, "COM7": true, "COM8": true, "COM9": true,
+	"LPT1": true, "LPT2": true, "LPT3": true, "LPT4": true, "LPT5": true, "LPT6": true, "LPT7": true, "LPT8": true, "LPT9": true,
+}
+
+// SanitiseFilename turns a canvas file or folder name into one that's valid on windows, macos and linux:
+// path separators become "-", other invalid characters become "_", trailing dots and spaces are dropped,
+// reserved device names are prefixed with "_" and long names are shortened, keeping their extension.
+// The same name always gives the same result.
+func SanitiseFilename(name string) string {
+	var b strings.Builder
+	for _, r := range name {
+		switch {
+		case r == '/' || r == '\\':
+			b.WriteRune('-')
+		case r < 0x20 || r == 0x7f || strings.ContainsRune(`<>:"|?*`, r):
+			b.WriteRune('_')
+		default:
+			b.WriteRune(r)
+		}
+	}
+	sanitised := strings.TrimRight(strings.TrimSpace(b.String()), ". ")
+	if sanitised == "" {
+		return "_"
+	}
+	base, _, _ := strings.Cut(sanitised, ".")
+	if reservedFilenames[strings.ToUpper(strings.TrimSpace(base))] {
+		sanitised = "_" + sanitised
+	}
+	if len(sanitised) > MAX_FILENAME_LENGTH {
+		ext := filepath.Ext(sanitised)
+		if len(ext) > MAX_FILENAME_LENGTH/2 {
+			ext = ""
+		}
+		// shortening can leave a trailing dot or space again
+		sanitised = strings.TrimRight(truncateUTF8(strings.TrimSuffix(sanitised, ext), MAX_FILENAME_LENGTH-len(ext)), ". ") + ext
+	}
+	return sanitised
+}
+
+// WithNameSuffix adds a suffix to a file name before its extension e.g. "notes (2).pdf"
+func WithNameSuffix(name string, suffix string) string {
+	ext := filepath.Ext(name)
+	base := strings.TrimSuffix(name, ext)
+	if len(base)+len(suffix)+len(ext) > MAX_FILENAME_LENGTH {
+		base = truncateUTF8(base, MAX_FILENAME_LENGTH-len(suffix)-len(ext))
+	}
+	return fmt.Sprintf("%s%s%s", base, suffix, ext)
+}
+
+// truncateUTF8 shortens s to at most n bytes without splitting a character
+func truncateUTF8(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
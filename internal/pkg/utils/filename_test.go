@@ -0,0 +1,91 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestSanitiseFilename(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"valid", "Lecture 1.pdf", "Lecture 1.pdf"},
+		{"path separators", "Week 1/2\\3", "Week 1-2-3"},
+		{"invalid characters", `a<b>c:d"e|f?g*h`, "a_b_c_d_e_f_g_h"},
+		{"control characters", "a\tb\x7f", "a_b_"},
+		{"trailing dots and spaces", "notes. . ", "notes"},
+		{"surrounding spaces", "  notes.pdf ", "notes.pdf"},
+		{"only dots", "...", "_"},
+		{"empty", "", "_"},
+		{"reserved name", "CON", "_CON"},
+		{"reserved name with extension", "nul.txt", "_nul.txt"},
+		{"reserved name with several extensions", "com1.tar.gz", "_com1.tar.gz"},
+		{"reserved name with trailing dot", "AUX.", "_AUX"},
+		{"reserved name as a prefix", "CONSOLE.txt", "CONSOLE.txt"},
+		{"unicode", "讲义 – 第一周.pdf", "讲义 – 第一周.pdf"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SanitiseFilename(tt.in); got != tt.want {
+				t.Errorf("SanitiseFilename(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+			if got := SanitiseFilename(SanitiseFilename(tt.in)); got != SanitiseFilename(tt.in) {
+				t.Errorf("sanitising %q twice gave %q", tt.in, got)
+			}
+		})
+	}
+}
+
+func TestSanitiseFilenameLength(t *testing.T) {
+	tests := []struct {
+		name    string
+		in      string
+		wantExt string
+	}{
+		{"keeps extension", strings.Repeat("a", 300) + ".pdf", ".pdf"},
+		{"multi-byte characters", strings.Repeat("é", 200) + ".pdf", ".pdf"},
+		{"drops long extension", "a." + strings.Repeat("b", 300), ""},
+		{"trailing space after shortening", strings.Repeat("a", 251) + "    " + strings.Repeat("b", 10) + "." + strings.Repeat("c", 200), ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := SanitiseFilename(tt.in)
+			if len(got) > MAX_FILENAME_LENGTH {
+				t.Errorf("got %d bytes, want at most %d", len(got), MAX_FILENAME_LENGTH)
+			}
+			if !utf8.ValidString(got) {
+				t.Error("split a character")
+			}
+			if tt.wantExt != "" && !strings.HasSuffix(got, tt.wantExt) {
+				t.Errorf("got %q, want it to keep %s", got, tt.wantExt)
+			}
+			if strings.TrimRight(got, ". ") != got {
+				t.Errorf("got %q ending with a dot or space", got)
+			}
+		})
+	}
+}
+
+func TestWithNameSuffix(t *testing.T) {
+	tests := []struct {
+		name   string
+		in     string
+		suffix string
+		want   string
+	}{
+		{"before extension", "notes.pdf", " (12)", "notes (12).pdf"},
+		{"no extension", "notes", " (12)", "notes (12)"},
+		{"last extension only", "archive.tar.gz", " (12)", "archive.tar (12).gz"},
+		{"long name", strings.Repeat("a", 255) + ".pdf", " (12)", strings.Repeat("a", 255-len(" (12).pdf")) + " (12).pdf"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := WithNameSuffix(tt.in, tt.suffix); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
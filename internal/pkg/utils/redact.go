@@ -0,0 +1,115 @@
+package utils
+
+import (
+	"io"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/mattn/go-colorable"
+)
+
+const (
+	REDACTED          = "[REDACTED]"
+	MIN_SECRET_LENGTH = 4
+)
+
+var (
+	secretsMu sync.RWMutex
+	secrets   []string
+	// access tokens passed as query params or bearer tokens, even if never registered
+	tokenPatterns = []*regexp.Regexp{
+		regexp.MustCompile(`(?i)(access_token=)[^&\s"']+`),
+		regexp.MustCompile(`(?i)(bearer\s+)[^\s"']+`),
+	}
+)
+
+// RegisterSecret marks a value (e.g. access token, password) that must never be printed
+func RegisterSecret(secret string) {
+	// too short to redact without mangling unrelated output
+	if len(secret) < MIN_SECRET_LENGTH {
+		return
+	}
+	secretsMu.Lock()
+	defer secretsMu.Unlock()
+	secrets = append(secrets, secret)
+}
+
+// Redact replaces all registered secrets and access tokens in s
+func Redact(s string) string {
+	secretsMu.RLock()
+	for _, secret := range secrets {
+		s = strings.ReplaceAll(s, secret, REDACTED)
+	}
+	secretsMu.RUnlock()
+	for _, pattern := range tokenPatterns {
+		s = pattern.ReplaceAllString(s, "${1}"+REDACTED)
+	}
+	return s
+}
+
+type redactingWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+	// end of the previous writes that could be the start of a secret, held back until the next write completes it
+	pending []byte
+}
+
+// NewRedactingWriter wraps w, redacting secrets from everything written to it, including secrets split across writes
+func NewRedactingWriter(w io.Writer) io.Writer {
+	return &redactingWriter{w: w}
+}
+
+func (r *redactingWriter) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	buffered := string(r.pending) + string(p)
+	cut := len(buffered) - heldBack(buffered)
+	r.pending = []byte(buffered[cut:])
+	if _, err := io.WriteString(r.w, Redact(buffered[:cut])); err != nil {
+		return 0, err
+	}
+	// report the original length so callers don't treat redaction as a short write
+	return len(p), nil
+}
+
+// heldBack returns how many bytes at the end of s could be the start of a registered secret, at most the secret's
+// length minus one. Complete secrets are left to Redact, so nothing is held back from inside one
+func heldBack(s string) int {
+	secretsMu.RLock()
+	defer secretsMu.RUnlock()
+	held := 0
+	for _, secret := range secrets {
+		for n := min(len(secret)-1, len(s)); n > held; n-- {
+			if strings.HasSuffix(s, secret[:n]) && !insideSecret(s, len(s)-n) {
+				held = n
+				break
+			}
+		}
+	}
+	return held
+}
+
+// insideSecret reports whether i falls strictly inside a registered secret in s, secretsMu must be held
+func insideSecret(s string, i int) bool {
+	for _, secret := range secrets {
+		for start := 0; start < i; {
+			found := strings.Index(s[start:], secret)
+			if found < 0 {
+				break
+			}
+			found += start
+			if found < i && i < found+len(secret) {
+				return true
+			}
+			start = found + 1
+		}
+	}
+	return false
+}
+
+// Stdout/Stderr redact secrets from all output, used for pterm, spinners and logs
+var (
+	Stdout = NewRedactingWriter(colorable.NewColorableStdout())
+	Stderr = NewRedactingWriter(colorable.NewColorableStderr())
+)
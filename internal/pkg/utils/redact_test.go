@@ -0,0 +1,104 @@
+package utils
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+// withSecrets registers secrets for the duration of a test
+func withSecrets(t *testing.T, registered ...string) {
+	t.Helper()
+	secretsMu.Lock()
+	saved := secrets
+	secrets = nil
+	secretsMu.Unlock()
+	t.Cleanup(func() {
+		secretsMu.Lock()
+		defer secretsMu.Unlock()
+		secrets = saved
+	})
+	for _, secret := range registered {
+		RegisterSecret(secret)
+	}
+}
+
+func TestRedact(t *testing.T) {
+	withSecrets(t, "1234~secrettoken", "hunter2", "abc")
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"registered secret", "token is 1234~secrettoken.", "token is [REDACTED]."},
+		{"every occurrence", "hunter2 hunter2", "[REDACTED] [REDACTED]"},
+		{"too short to register", "abc", "abc"},
+		{"query param", "GET /api/v1/courses?access_token=xyz789&page=2", "GET /api/v1/courses?access_token=[REDACTED]&page=2"},
+		{"bearer token", `Authorization: Bearer xyz789"`, `Authorization: Bearer [REDACTED]"`},
+		{"nothing to redact", "Downloaded files: /home/user/canvas", "Downloaded files: /home/user/canvas"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Redact(tt.in); got != tt.want {
+				t.Errorf("Redact(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRedactingWriterSplitSecret(t *testing.T) {
+	secret := "1234~secrettoken"
+	withSecrets(t, secret)
+	line := "token is " + secret + "\n"
+	for split := 1; split < len(line); split++ {
+		var b bytes.Buffer
+		w := NewRedactingWriter(&b)
+		for _, chunk := range []string{line[:split], line[split:]} {
+			if n, err := w.Write([]byte(chunk)); err != nil || n != len(chunk) {
+				t.Fatalf("Write(%q) = %d, %v", chunk, n, err)
+			}
+		}
+		if got := b.String(); got != "token is [REDACTED]\n" {
+			t.Errorf("split at %d wrote %q", split, got)
+		}
+	}
+
+	var b bytes.Buffer
+	w := NewRedactingWriter(&b)
+	for _, c := range line {
+		w.Write([]byte(string(c)))
+	}
+	if got := b.String(); got != "token is [REDACTED]\n" {
+		t.Errorf("byte by byte wrote %q", got)
+	}
+}
+
+func TestRedactingWriterHoldsBackOnlyPossibleSecrets(t *testing.T) {
+	withSecrets(t, "1234~secrettoken", "5678~othertoken")
+	var b bytes.Buffer
+	w := NewRedactingWriter(&b)
+
+	w.Write([]byte("Downloading 3 files"))
+	if got := b.String(); got != "Downloading 3 files" {
+		t.Errorf("wrote %q, want everything that can't start a secret right away", got)
+	}
+	w.Write([]byte(", course 12"))
+	if got := b.String(); got != "Downloading 3 files, course " {
+		t.Errorf("wrote %q, want the possible start of a secret held back", got)
+	}
+	w.Write([]byte("3 done\n"))
+	if got := b.String(); got != "Downloading 3 files, course 123 done\n" {
+		t.Errorf("wrote %q, want the held back bytes once they can't be a secret", got)
+	}
+
+	// a secret ending where another begins
+	b.Reset()
+	w.Write([]byte("1234~secrettoken5678~"))
+	w.Write([]byte("othertoken\n"))
+	if got := b.String(); got != "[REDACTED][REDACTED]\n" {
+		t.Errorf("wrote %q, want both secrets redacted", got)
+	}
+	if strings.Contains(b.String(), "token") {
+		t.Errorf("leaked part of a secret: %q", b.String())
+	}
+}
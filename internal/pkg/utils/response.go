@@ -10,13 +10,12 @@ import (
 	"github.com/pterm/pterm"
 )
 
-func SetQueryAccessToken(req *http.Request, accessToken string) {
+// SetAuthorizationHeader authorises req with a bearer token, keeping the token out of the url
+func SetAuthorizationHeader(req *http.Request, accessToken string) {
 	if accessToken == "" {
 		return
 	}
-	q := req.URL.Query()
-	q.Set("access_token", accessToken)
-	req.URL.RawQuery = q.Encode()
+	req.Header.Set("Authorization", "Bearer "+accessToken)
 }
 
 func ExtractResponseToString(res *http.Response) string {
@@ -0,0 +1,41 @@
+package utils
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// FormatSize renders a byte count in human readable units e.g. 1.5 MB
+func FormatSize(size int64) string {
+	const unit = 1000
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(size)/float64(div), "kMGTPE"[exp])
+}
+
+// ParseSize parses a human readable size e.g. 500, 10kB, 50MB or 1.5GB into bytes
+func ParseSize(raw string) (int64, error) {
+	s := strings.ToUpper(strings.TrimSpace(raw))
+	s = strings.TrimSuffix(s, "B")
+	multiplier := int64(1)
+	for i, unit := range "KMGTPE" {
+		if strings.HasSuffix(s, string(unit)) {
+			s = strings.TrimSuffix(s, string(unit))
+			multiplier = int64(math.Pow(1000, float64(i+1)))
+			break
+		}
+	}
+	value, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	if err != nil || value < 0 {
+		return 0, fmt.Errorf("invalid size '%s', expected e.g. 500kB, 50MB or 1.5GB", raw)
+	}
+	return int64(value * float64(multiplier)), nil
+}